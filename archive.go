@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// what import does with a record whose path is already taken
+const (
+	CONFLICT_FAIL      = "fail"
+	CONFLICT_SKIP      = "skip"
+	CONFLICT_OVERWRITE = "overwrite"
+	CONFLICT_RENAME    = "rename"
+)
+
+// what import does with each record
+const (
+	IMPORT_CREATE    = "create"
+	IMPORT_OVERWRITE = "overwrite"
+	IMPORT_RENAME    = "rename"
+	IMPORT_SKIP      = "skip"
+	IMPORT_UNCHANGED = "unchanged"
+)
+
+// ArchiveRecord is a note in a json archive. the header is rebuilt from header on import, title, tags and fields
+// are there for other tools reading the archive
+type ArchiveRecord struct {
+	// relative to the notebook, always with / separators
+	Path  string   `json:"path"`
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+	// keyed by the lower cased field name like -o json, the last of a repeated field wins
+	Fields Fields `json:"fields"`
+	// every header field as it was written, in order
+	Header  []Field   `json:"header"`
+	Content string    `json:"content"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// NewArchiveRecords makes a record of every note, sorted by path
+func NewArchiveRecords(root string, notes []Note) ([]ArchiveRecord, error) {
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Path < notes[j].Path })
+	records := make([]ArchiveRecord, 0, len(notes))
+	for _, note := range notes {
+		info, err := os.Stat(note.Path)
+		if err != nil {
+			return nil, fmt.Errorf("could not stat file: %v, %w", note.Path, err)
+		}
+		record := ArchiveRecord{
+			Path:    indexKey(root, note.Path),
+			Title:   note.Title,
+			Tags:    note.Tags,
+			Fields:  note.Fields,
+			Header:  note.Fields,
+			Content: note.Content,
+			ModTime: info.ModTime(),
+		}
+		if record.Tags == nil {
+			record.Tags = []string{}
+		}
+		if record.Fields == nil {
+			record.Fields = Fields{}
+			record.Header = []Field{}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ReadArchive reads records written either as a json array or as ndjson
+func ReadArchive(reader io.Reader) ([]ArchiveRecord, error) {
+	in := bufio.NewReader(reader)
+	var first byte
+	for {
+		b, err := in.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return []ArchiveRecord{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read archive: %w", err)
+		}
+		if !unicode.IsSpace(rune(b)) {
+			first = b
+			_ = in.UnreadByte()
+			break
+		}
+	}
+
+	decoder := json.NewDecoder(in)
+	if first == '[' {
+		var records []ArchiveRecord
+		if err := decoder.Decode(&records); err != nil {
+			return nil, fmt.Errorf("could not read archive: %w", err)
+		}
+		return records, nil
+	}
+	records := make([]ArchiveRecord, 0)
+	for {
+		var record ArchiveRecord
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read record %v: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// archivePath works out where a record goes in the notebook, records can't be written outside of it
+func archivePath(root, recordPath string) (string, error) {
+	if len(strings.TrimSpace(recordPath)) == 0 {
+		return "", fmt.Errorf("record has no path")
+	}
+	clean, err := cleanNotebookPath(recordPath)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := noteExtension(clean); !ok {
+		clean += settings.Extension
+	}
+	return filepath.Join(root, filepath.FromSlash(clean)), nil
+}
+
+// contents is the note a record is written as at filePath, in whatever format its extension says
+func (r ArchiveRecord) contents(filePath string) ([]byte, error) {
+	fields := Fields(r.Header)
+	if fields == nil {
+		// archives from before header was added only have the lower cased fields
+		fields = r.Fields
+	}
+	if fields == nil {
+		// records made by other tools might only have a title and tags
+		fields = Fields{}
+		if len(r.Title) > 0 {
+			fields = append(fields, Field{Key: "title", Value: r.Title})
+		}
+		if len(r.Tags) > 0 {
+			fields = append(fields, Field{Key: "tags", Value: strings.Join(r.Tags, ", ")})
+		}
+	}
+	// notes that were read without a header go back the way they were
+	if len(fields) == 0 {
+		return []byte(r.Content), nil
+	}
+
+	format := formatFor(filePath)
+	data, err := format.Render(fields, r.Content)
+	if err != nil {
+		return nil, err
+	}
+	// anything the note was read with can be written back, as long as it reads the same afterwards
+	note, err := format.Parse(bytes.NewReader(data), filePath, true)
+	if err != nil {
+		return nil, fmt.Errorf("header would not be valid: %w", err)
+	}
+	for i, field := range fields {
+		if i >= len(note.Fields) || note.Fields[i] != field {
+			return nil, fmt.Errorf("header field `%v` can not be written in a %v note", field.Key, format.Name())
+		}
+	}
+	if len(note.Fields) != len(fields) {
+		return nil, fmt.Errorf("header would not be read back the same in a %v note", format.Name())
+	}
+	return data, nil
+}
+
+// sameNote is whether existing already has the fields and content of data, even if it's laid out differently,
+// e.g. with the values lined up
+func sameNote(filePath string, existing, data []byte) bool {
+	if bytes.Equal(existing, data) {
+		return true
+	}
+	format := formatFor(filePath)
+	have, err := format.Parse(bytes.NewReader(existing), filePath, false)
+	if err != nil {
+		return false
+	}
+	want, err := format.Parse(bytes.NewReader(data), filePath, false)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(have.Fields, want.Fields) && have.Content == want.Content
+}
+
+// ImportResult is what importing a record does, Path is where it ends up
+type ImportResult struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	data   []byte
+	mtime  time.Time
+}
+
+// freeNotePath numbers filePath until it isn't taken
+func freeNotePath(filePath string, taken func(string) bool) string {
+	ext, _ := noteExtension(filePath)
+	base := strings.TrimSuffix(filePath, ext)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%v-%v%v", base, i, ext)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// PlanImport works out what importing every record into the notebook at root does, before anything is written.
+// records whose path is taken, either by a note or an earlier record, are handled as onConflict says
+func PlanImport(root string, records []ArchiveRecord, onConflict string) ([]ImportResult, error) {
+	switch onConflict {
+	case CONFLICT_FAIL, CONFLICT_SKIP, CONFLICT_OVERWRITE, CONFLICT_RENAME:
+	default:
+		return nil, fmt.Errorf("unknown conflict handling `%v`, expected one of %v, %v, %v or %v", onConflict, CONFLICT_FAIL, CONFLICT_SKIP, CONFLICT_OVERWRITE, CONFLICT_RENAME)
+	}
+
+	planned := make(map[string]bool)
+	taken := func(filePath string) bool {
+		return planned[filePath] || exists(filePath)
+	}
+	results := make([]ImportResult, 0, len(records))
+	conflicts := make([]string, 0)
+	for i, record := range records {
+		filePath, err := archivePath(root, record.Path)
+		if err != nil {
+			return nil, fmt.Errorf("record %v: %w", i+1, err)
+		}
+		data, err := record.contents(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("record %v (%v): %w", i+1, record.Path, err)
+		}
+
+		result := ImportResult{Path: filePath, Action: IMPORT_CREATE, data: data, mtime: record.ModTime}
+		if taken(filePath) {
+			existing, err := os.ReadFile(filePath)
+			switch {
+			case !planned[filePath] && err == nil && sameNote(filePath, existing, data):
+				result.Action = IMPORT_UNCHANGED
+			case onConflict == CONFLICT_SKIP:
+				result.Action = IMPORT_SKIP
+				result.Reason = "already exists"
+			case onConflict == CONFLICT_OVERWRITE:
+				result.Action = IMPORT_OVERWRITE
+			case onConflict == CONFLICT_RENAME:
+				result.Path = freeNotePath(filePath, taken)
+				result.Action = IMPORT_RENAME
+				result.Reason = fmt.Sprintf("`%v` already exists", filePath)
+			default:
+				conflicts = append(conflicts, filePath)
+			}
+		}
+		planned[result.Path] = true
+		results = append(results, result)
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%v note(s) already exist: %v. pick what to do with them with --on-conflict %v, %v or %v",
+			len(conflicts), strings.Join(conflicts, ", "), CONFLICT_SKIP, CONFLICT_OVERWRITE, CONFLICT_RENAME)
+	}
+	return results, nil
+}
+
+// applyImport writes a planned record, existing notes are backed up before they're overwritten
+func applyImport(result ImportResult) error {
+	if result.Action == IMPORT_SKIP || result.Action == IMPORT_UNCHANGED {
+		return nil
+	}
+	if exists(result.Path) {
+		if err := writeNoteFile(result.Path, result.data); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(result.Path), 0770); err != nil {
+			return err
+		}
+		if err := writeFileAtomic(result.Path, result.data, 0666); err != nil {
+			return fmt.Errorf("could not create file (at path: %v): %w", result.Path, err)
+		}
+	}
+	if !result.mtime.IsZero() {
+		if err := os.Chtimes(result.Path, result.mtime, result.mtime); err != nil {
+			return fmt.Errorf("could not set modification time: %v, %w", result.Path, err)
+		}
+	}
+	return nil
+}
+
+var (
+	archiveOut     string
+	importConflict string
+	importDryRun   bool
+)
+
+var exportJSONCmd = &cobra.Command{
+	Use:   "json",
+	Short: "exports every note as a json archive",
+	Long: `exports every note as a json archive with a record per note: its path relative to the notebook, title, tags,
+header fields keyed by lower cased name, every header field as it was written, content and modification time.
+-o ndjson writes a record per line instead of a json array`,
+	Example: "notes export json [--out notes.json]\nnotes export json -o ndjson | other-tool",
+	Args:    cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		root, err := notesRoot()
+		if err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		notes, err := collectFiles(false)
+		if err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		records, err := NewArchiveRecords(root, notes)
+		if err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		if len(archiveOut) == 0 {
+			if err := encodeRecords(os.Stdout, records, outputFormat == OUTPUT_NDJSON); err != nil {
+				fmt.Printf("Problem trying to export: %v", err)
+			}
+			return
+		}
+		var out bytes.Buffer
+		if err := encodeRecords(&out, records, outputFormat == OUTPUT_NDJSON); err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		if err := writeFileAtomic(archiveOut, out.Bytes(), 0666); err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		fmt.Printf("Exported %v notes to %v\n", len(records), archiveOut)
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "imports notes exported from a notebook",
+}
+
+var importJSONCmd = &cobra.Command{
+	Use:   "json",
+	Short: "recreates notes from a json archive",
+	Long: `recreates notes from a json or ndjson archive made by export json, reading from stdin if no file is given.
+each note's header is rebuilt from its header fields in the format of its extension. nothing is written if a note
+already exists, unless --on-conflict says to skip it, overwrite it (keeping a backup) or import it under a new name.
+notes that already match the archive are left alone`,
+	Example: "notes import json [--on-conflict fail|skip|overwrite|rename] [--dry-run] [notes.json]",
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		root, err := notesRoot()
+		if err != nil {
+			fmt.Printf("Problem trying to import: %v", err)
+			return
+		}
+		var in io.Reader = os.Stdin
+		if len(args) > 0 && args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				fmt.Printf("Problem trying to import: %v", err)
+				return
+			}
+			defer file.Close()
+			in = file
+		}
+		records, err := ReadArchive(in)
+		if err != nil {
+			fmt.Printf("Problem trying to import: %v", err)
+			return
+		}
+		results, err := PlanImport(root, records, importConflict)
+		if err != nil {
+			fmt.Printf("Problem trying to import: %v", err)
+			return
+		}
+		if !importDryRun {
+			for _, result := range results {
+				if err := applyImport(result); err != nil {
+					fmt.Printf("Problem trying to import: %v", err)
+					return
+				}
+			}
+		}
+
+		verb := map[string]string{IMPORT_CREATE: "Created", IMPORT_OVERWRITE: "Overwrote", IMPORT_RENAME: "Created"}
+		if importDryRun {
+			verb = map[string]string{IMPORT_CREATE: "Would create", IMPORT_OVERWRITE: "Would overwrite", IMPORT_RENAME: "Would create"}
+		}
+		err = writeOutput(results, func(result ImportResult) {
+			switch result.Action {
+			case IMPORT_SKIP:
+				fmt.Printf("Skipped %v: %v\n", result.Path, result.Reason)
+			case IMPORT_UNCHANGED:
+				fmt.Printf("Unchanged %v\n", result.Path)
+			case IMPORT_RENAME:
+				fmt.Printf("%v %v, %v\n", verb[result.Action], result.Path, result.Reason)
+			default:
+				fmt.Printf("%v %v\n", verb[result.Action], result.Path)
+			}
+		})
+		if err != nil {
+			fmt.Printf("Problem trying to output imported notes: %v", err)
+		}
+	},
+}
+
+func init() {
+	exportJSONCmd.Flags().StringVar(&archiveOut, "out", "", "file to write the archive to instead of stdout")
+	exportCmd.AddCommand(exportJSONCmd)
+
+	importJSONCmd.Flags().StringVar(&importConflict, "on-conflict", CONFLICT_FAIL, "what to do with notes that already exist: "+CONFLICT_FAIL+", "+CONFLICT_SKIP+", "+CONFLICT_OVERWRITE+" or "+CONFLICT_RENAME)
+	importJSONCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "report what would be imported without writing anything")
+	importCmd.AddCommand(importJSONCmd)
+}
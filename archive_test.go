@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	from := t.TempDir()
+	write := func(root, name, contents string) string {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+			t.Fatalf("could not create test directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0660); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+		return path
+	}
+	originals := map[string]string{
+		"work/plan.txt": "title: Plan\ntags: a, b\nstatus: open\n------\n\nbody\n",
+		"idea.md":       "---\ntitle: Idea\ntags: [x]\n---\nmd body\n",
+		"README.txt":    "no header\n",
+	}
+	fileList := make([]string, 0)
+	for name, contents := range originals {
+		fileList = append(fileList, write(from, name, contents))
+	}
+	modTime := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(from, "idea.md"), modTime, modTime); err != nil {
+		t.Fatalf("could not set modification time: %v", err)
+	}
+
+	notes, _ := parseFiles(fileList, false)
+	records, err := NewArchiveRecords(from, notes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 3 || records[0].Path != "README.txt" || records[2].Path != "work/plan.txt" {
+		t.Fatalf("expected records sorted by relative path, got %+v", records)
+	}
+
+	for _, ndjson := range []bool{false, true} {
+		var archive bytes.Buffer
+		if err := encodeRecords(&archive, records, ndjson); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		read, err := ReadArchive(&archive)
+		if err != nil {
+			t.Fatalf("unexpected error reading archive (ndjson: %v): %v", ndjson, err)
+		}
+		if len(read) != len(records) || !reflect.DeepEqual(records[2].Fields, read[2].Fields) || !read[1].ModTime.Equal(modTime) {
+			t.Errorf("archive mismatch (ndjson: %v):\nexpected: %+v\ngot: %+v", ndjson, records, read)
+		}
+	}
+
+	to := t.TempDir()
+	t.Setenv(ROOT_ENV, to)
+	results, err := PlanImport(to, records, CONFLICT_FAIL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, result := range results {
+		if result.Action != IMPORT_CREATE {
+			t.Errorf("expected %v to be created, got %v", result.Path, result.Action)
+		}
+		if err := applyImport(result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for name, contents := range originals {
+		if data, err := os.ReadFile(filepath.Join(to, filepath.FromSlash(name))); err != nil || string(data) != contents {
+			t.Errorf("%v mismatch:\nexpected: %q\ngot: %q %v", name, contents, data, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(to, "idea.md")); err != nil || !info.ModTime().Equal(modTime) {
+		t.Errorf("expected the modification time to be kept, got %v %v", info.ModTime(), err)
+	}
+
+	// importing the same archive again changes nothing
+	results, err = PlanImport(to, records, CONFLICT_FAIL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, result := range results {
+		if result.Action != IMPORT_UNCHANGED {
+			t.Errorf("expected %v to be unchanged, got %v", result.Path, result.Action)
+		}
+	}
+}
+
+func TestArchiveTestNotes(t *testing.T) {
+	from, err := filepath.Abs("test_notes")
+	if err != nil {
+		t.Fatalf("could not find test notes: %v", err)
+	}
+	notes, _ := parseFiles(files(from), false)
+	records, err := NewArchiveRecords(from, notes)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var archive bytes.Buffer
+	if err := encodeRecords(&archive, records, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	read, err := ReadArchive(&archive)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// importing into an empty notebook brings back every note with the same header fields and content
+	to := t.TempDir()
+	t.Setenv(ROOT_ENV, to)
+	results, err := PlanImport(to, read, CONFLICT_FAIL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, result := range results {
+		if err := applyImport(result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for _, note := range notes {
+		rel, _ := filepath.Rel(from, note.Path)
+		imported, err := parseNoteFile(filepath.Join(to, rel), false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(note.Fields, imported.Fields) || note.Content != imported.Content {
+			t.Errorf("%v mismatch:\nexpected: %+v %q\ngot: %+v %q", rel, note.Fields, note.Content, imported.Fields, imported.Content)
+		}
+	}
+
+	// and importing it back into the notebook it came from changes nothing
+	results, err = PlanImport(from, read, CONFLICT_FAIL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, result := range results {
+		if result.Action != IMPORT_UNCHANGED {
+			t.Errorf("expected %v to be unchanged, got %v", result.Path, result.Action)
+		}
+	}
+}
+
+func TestPlanImportConflicts(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "plan.txt")
+	if err := os.WriteFile(existing, []byte("title: old\n------\n"), 0660); err != nil {
+		t.Fatalf("could not write test file: %v", err)
+	}
+	records := []ArchiveRecord{
+		{Path: "plan", Title: "New", Tags: []string{"a", "b"}, Content: "new\n"},
+		{Path: "plan.txt", Fields: Fields{{Key: "title", Value: "again"}}},
+	}
+
+	if _, err := PlanImport(root, records, CONFLICT_FAIL); err == nil || !strings.Contains(err.Error(), "2 note(s) already exist") {
+		t.Errorf("expected both conflicts to be reported, got %v", err)
+	}
+	if _, err := PlanImport(root, records, "merge"); err == nil {
+		t.Errorf("expected unknown conflict handling to be rejected")
+	}
+
+	tests := []struct {
+		onConflict string
+		want       []ImportResult
+	}{
+		{CONFLICT_SKIP, []ImportResult{
+			{Path: existing, Action: IMPORT_SKIP, Reason: "already exists"},
+			{Path: existing, Action: IMPORT_SKIP, Reason: "already exists"},
+		}},
+		{CONFLICT_OVERWRITE, []ImportResult{
+			{Path: existing, Action: IMPORT_OVERWRITE},
+			{Path: existing, Action: IMPORT_OVERWRITE},
+		}},
+		{CONFLICT_RENAME, []ImportResult{
+			{Path: filepath.Join(root, "plan-2.txt"), Action: IMPORT_RENAME, Reason: "`" + existing + "` already exists"},
+			{Path: filepath.Join(root, "plan-3.txt"), Action: IMPORT_RENAME, Reason: "`" + existing + "` already exists"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.onConflict, func(t *testing.T) {
+			results, err := PlanImport(root, records, tt.onConflict)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i := range results {
+				results[i].data = nil
+				results[i].mtime = time.Time{}
+			}
+			if !reflect.DeepEqual(tt.want, results) {
+				t.Errorf("results mismatch:\nexpected: %+v\ngot: %+v", tt.want, results)
+			}
+		})
+	}
+
+	results, err := PlanImport(root, records[:1], CONFLICT_OVERWRITE)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "title: New\ntags: a, b\n------\nnew\n"; string(results[0].data) != want {
+		t.Errorf("expected a header from the title and tags:\nexpected: %q\ngot: %q", want, results[0].data)
+	}
+
+	for _, bad := range []string{"", "../outside.txt", "/etc/notes.txt", ".notes/index", "a/../../b.txt"} {
+		if _, err := PlanImport(root, []ArchiveRecord{{Path: bad}}, CONFLICT_SKIP); err == nil {
+			t.Errorf("expected path %q to be rejected", bad)
+		}
+	}
+}
+
+func TestFieldsUnmarshalJSON(t *testing.T) {
+	var fields Fields
+	if err := json.Unmarshal([]byte(`{"title":"a","zeta":"1","alpha":"2"}`), &fields); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Fields{{Key: "title", Value: "a"}, {Key: "zeta", Value: "1"}, {Key: "alpha", Value: "2"}}
+	if !reflect.DeepEqual(want, fields) {
+		t.Errorf("fields mismatch:\nexpected: %+v\ngot: %+v", want, fields)
+	}
+	if err := json.Unmarshal([]byte(`{"title":["a"]}`), &fields); err == nil {
+		t.Errorf("expected an error for a field that isn't text")
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// where the previous version of every rewritten note is kept, inside the notebook's metadata directory
+const BACKUP_DIR = "backups"
+
+// writeTemp writes data into the temporary file, swapped out by tests to simulate a write getting interrupted
+var writeTemp = func(f *os.File, data []byte) error {
+	_, err := f.Write(data)
+	return err
+}
+
+// createTemp is os.CreateTemp, except that the file is created with perm less the umask rather than 0600
+func createTemp(dir, prefix string, perm os.FileMode) (*os.File, error) {
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, prefix+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, os.ErrExist) && try < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
+
+// writeFileAtomic replaces filePath with data by writing a temporary file next to it and renaming it over the
+// original, so an interrupted write leaves either the old contents or the new ones and never a mix of both.
+// an existing file keeps its permissions, new files get perm less the umask like they would from os.WriteFile
+func writeFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	// renaming over a symlink would replace the link rather than the note it points to
+	if resolved, err := filepath.EvalSymlinks(filePath); err == nil {
+		filePath = resolved
+	}
+	keepPerm := false
+	if info, err := os.Stat(filePath); err == nil {
+		perm = info.Mode().Perm()
+		keepPerm = true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not stat file: %v, %w", filePath, err)
+	}
+
+	// the temporary name doesn't end with the note extension, so a leftover one is never mistaken for a note
+	tmp, err := createTemp(filepath.Dir(filePath), "."+filepath.Base(filePath), perm)
+	if err != nil {
+		return fmt.Errorf("could not create temporary file for %v: %w", filePath, err)
+	}
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := writeTemp(tmp, data); err != nil {
+		return cleanup(fmt.Errorf("could not write file: %v, %w", filePath, err))
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(fmt.Errorf("could not sync file: %v, %w", filePath, err))
+	}
+	// the umask could have taken away some of the permissions the existing file has
+	if keepPerm {
+		if err := tmp.Chmod(perm); err != nil {
+			return cleanup(fmt.Errorf("could not set permissions of file: %v, %w", filePath, err))
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return cleanup(fmt.Errorf("could not write file: %v, %w", filePath, err))
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return cleanup(fmt.Errorf("could not replace file: %v, %w", filePath, err))
+	}
+	if err := syncDir(filepath.Dir(filePath)); err != nil {
+		return fmt.Errorf("could not sync directory of file: %v, %w", filePath, err)
+	}
+	return nil
+}
+
+// syncDir flushes a directory's entries to disk, without it a rename into the directory can be lost in a crash.
+// windows can't open directories for syncing, and doesn't need to
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// backupPath is where the previous version of the note at filePath is kept. notes outside of the notebook
+// get theirs next to them
+func backupPath(filePath string) (string, error) {
+	root, err := notesRoot()
+	if err != nil {
+		return "", err
+	}
+	key := indexKey(root, filePath)
+	if key == ".." || strings.HasPrefix(key, "../") || filepath.IsAbs(key) {
+		return filePath + ".bak", nil
+	}
+	return filepath.Join(root, METADATA_DIR, BACKUP_DIR, filepath.FromSlash(key)), nil
+}
+
+// backupNote copies the current contents of the note at filePath to its backup
+func backupNote(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("could not stat file: %v, %w", filePath, err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("could not read file: %v, %w", filePath, err)
+	}
+	backup, err := backupPath(filePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(backup), 0770); err != nil {
+		return fmt.Errorf("could not create backup directory: %w", err)
+	}
+	if err := writeFileAtomic(backup, data, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("could not back up file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "note.txt")
+	if err := os.WriteFile(filePath, []byte("old"), 0600); err != nil {
+		t.Fatalf("could not write note: %v", err)
+	}
+	if err := os.Chmod(filePath, 0600); err != nil {
+		t.Fatalf("could not chmod note: %v", err)
+	}
+
+	if err := writeFileAtomic(filePath, []byte("new"), 0660); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(filePath)
+	info, _ := os.Stat(filePath)
+	if string(data) != "new" || info.Mode().Perm() != 0600 {
+		t.Errorf("expected new contents with the old permissions, got %q %v", data, info.Mode().Perm())
+	}
+
+	// new files get the same permissions os.WriteFile would give them, umask included
+	for _, perm := range []os.FileMode{0640, 0666} {
+		created := filepath.Join(dir, fmt.Sprintf("created-%o.txt", perm))
+		if err := writeFileAtomic(created, []byte("fresh"), perm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		reference := filepath.Join(dir, fmt.Sprintf("reference-%o.txt", perm))
+		if err := os.WriteFile(reference, []byte("fresh"), perm); err != nil {
+			t.Fatalf("could not write reference file: %v", err)
+		}
+		info, _ := os.Stat(created)
+		want, _ := os.Stat(reference)
+		if info == nil || want == nil || info.Mode().Perm() != want.Mode().Perm() {
+			t.Errorf("expected a new file to get %v, got %v", want.Mode().Perm(), info.Mode().Perm())
+		}
+	}
+
+	linked := filepath.Join(dir, "linked.txt")
+	if err := os.Symlink(filePath, linked); err == nil {
+		if err := writeFileAtomic(linked, []byte("through link"), 0660); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info, _ := os.Lstat(linked); info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("expected the symlink to be kept")
+		}
+		if data, _ := os.ReadFile(filePath); string(data) != "through link" {
+			t.Errorf("expected the linked note to be written, got %q", data)
+		}
+	}
+}
+
+// interruptWrites makes atomic writes of filePath fail part way through, either with an error or by panicking
+// like the process was killed, which skips any cleanup. writes of other files, like its backup, go through
+func interruptWrites(t *testing.T, filePath string, kill bool) {
+	old := writeTemp
+	t.Cleanup(func() { writeTemp = old })
+	writeTemp = func(f *os.File, data []byte) error {
+		if filepath.Dir(f.Name()) != filepath.Dir(filePath) || !strings.HasPrefix(filepath.Base(f.Name()), "."+filepath.Base(filePath)+".") {
+			return old(f, data)
+		}
+		if _, err := f.Write(data[:len(data)/2]); err != nil {
+			return err
+		}
+		if kill {
+			panic("killed")
+		}
+		return errors.New("disk full")
+	}
+}
+
+func TestInterruptedWrites(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ROOT_ENV, root)
+	original := "title: diary\ntags:\n------\n\n2022-04-23:\nDear Diary,\n"
+	filePath := filepath.Join(root, "diary.txt")
+	if err := os.WriteFile(filePath, []byte(original), 0660); err != nil {
+		t.Fatalf("could not write note: %v", err)
+	}
+	backup := filepath.Join(root, METADATA_DIR, BACKUP_DIR, "diary.txt")
+	untouched := func() {
+		t.Helper()
+		if data, _ := os.ReadFile(filePath); string(data) != original {
+			t.Errorf("expected the note to be untouched, got %q", data)
+		}
+		if data, err := os.ReadFile(backup); err != nil || string(data) != original {
+			t.Errorf("expected the backup to have the original contents, got %q %v", data, err)
+		}
+	}
+
+	ts := time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local)
+	interruptWrites(t, filePath, false)
+	if _, err := addTimestamp(filePath, ts, EntryOptions{}); err == nil || !strings.Contains(err.Error(), "disk full") || strings.Contains(err.Error(), "back up") {
+		t.Errorf("expected the note's own write to be interrupted, got %v", err)
+	}
+	untouched()
+	if leftover, _ := filepath.Glob(filepath.Join(root, ".*.tmp")); len(leftover) != 0 {
+		t.Errorf("expected temporary files to be cleaned up, found %v", leftover)
+	}
+
+	interruptWrites(t, filePath, true)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected the write to be killed")
+			}
+		}()
+		SetNoteField(filePath, "status", "open")
+	}()
+	untouched()
+	if leftover, _ := filepath.Glob(filepath.Join(root, ".*.tmp")); len(leftover) != 1 {
+		t.Errorf("expected the killed write to leave its temporary file behind, found %v", leftover)
+	}
+	if notes := files(root); len(notes) != 1 || notes[0] != filePath {
+		t.Errorf("expected a leftover temporary file not to show up as a note, got %v", notes)
+	}
+}
+
+func TestWriteNoteFileBackup(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ROOT_ENV, root)
+	filePath := filepath.Join(root, "work", "q3.txt")
+	if err := NewNoteFile(filePath, NewNoteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	original, _ := os.ReadFile(filePath)
+	if err := SetNoteField(filePath, "status", "open"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	backup := filepath.Join(root, METADATA_DIR, BACKUP_DIR, "work", "q3.txt")
+	if data, err := os.ReadFile(backup); err != nil || string(data) != string(original) {
+		t.Errorf("expected the backup to have the original contents, got %q %v", data, err)
+	}
+
+	outside := filepath.Join(t.TempDir(), "elsewhere.txt")
+	if got, err := backupPath(outside); err != nil || got != outside+".bak" {
+		t.Errorf("expected notes outside the notebook to be backed up next to them, got %v %v", got, err)
+	}
+}
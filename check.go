@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type NoteProblem struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (p NoteProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%v: %v", p.Path, p.Message)
+	}
+	return fmt.Sprintf("%v:%v: %v", p.Path, p.Line, p.Message)
+}
+
+// CheckNote validates the header of a note, unlike ParseNote it keeps going after the first problem
+// so that everything wrong with the file can be reported at once
+func CheckNote(reader io.Reader, path string) []NoteProblem {
+	problems := make([]NoteProblem, 0)
+	report := func(line int, format string, a ...interface{}) {
+		problems = append(problems, NoteProblem{Path: path, Line: line, Message: fmt.Sprintf(format, a...)})
+	}
+
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	headerLines := 0
+	hasTitle := false
+	foundDivider := false
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == settings.Divider {
+			foundDivider = true
+			if headerLines == 0 {
+				report(lineNum, "%v", ErrEmptyHeader)
+			}
+			break
+		}
+		headerLines++
+
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			// the line number is already part of the problem, so only the line itself goes in the message
+			report(lineNum, "could not parse header line: %v", line)
+			continue
+		}
+		field = strings.TrimSpace(strings.ToLower(field))
+		if field == "title" {
+			hasTitle = len(strings.TrimSpace(value)) > 0
+		}
+		for _, problem := range checkHeaderField(field, strings.TrimSpace(value)) {
+			report(lineNum, "%v", problem)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		report(lineNum, "could not read file: %v", err)
+		return problems
+	}
+	if !foundDivider {
+		report(lineNum, "missing %v divider after header", settings.Divider)
+	}
+	if headerLines > 0 && !hasTitle {
+		report(0, "missing title")
+	}
+
+	return problems
+}
+
+// checkHeaderField validates a single header field, field has to be lower cased. any field can be set with
+// meta set, so only the fields notes knows what to do with have their values checked
+func checkHeaderField(field, value string) []string {
+	problems := make([]string, 0)
+	if len(field) == 0 {
+		return append(problems, "empty header field name")
+	}
+	switch field {
+	case ENTRY_ORDER_FIELD:
+		if _, err := entryOrder(nil, value); err != nil {
+			problems = append(problems, err.Error())
+		}
+	case "tags":
+		seen := make(map[string]bool)
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if len(tag) == 0 {
+				continue
+			}
+			if seen[strings.ToLower(tag)] {
+				problems = append(problems, fmt.Sprintf("duplicate tag %q", tag))
+			}
+			seen[strings.ToLower(tag)] = true
+		}
+	}
+	return problems
+}
+
+// checkParsedNote validates a note in any format. it stops at the first problem parsing the header, so it's only
+// used for formats CheckNote can't read
+func checkParsedNote(reader io.Reader, path string) []NoteProblem {
+	note, err := parseNote(reader, path, true)
+	if err != nil {
+		return []NoteProblem{{Path: path, Message: err.Error()}}
+	}
+	problems := make([]NoteProblem, 0)
+	for _, field := range note.Fields {
+		for _, problem := range checkHeaderField(strings.ToLower(field.Key), field.Value) {
+			problems = append(problems, NoteProblem{Path: path, Message: problem})
+		}
+	}
+	if len(strings.TrimSpace(note.Title)) == 0 {
+		problems = append(problems, NoteProblem{Path: path, Message: "missing title"})
+	}
+	return problems
+}
+
+// CheckNotes runs CheckNote against every note found in the given paths, directories are walked
+func CheckNotes(paths []string) ([]NoteProblem, error) {
+	fileList := make([]string, 0)
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not check path: %w", err)
+		}
+		if info.IsDir() {
+			fileList = append(fileList, files(p)...)
+		} else {
+			fileList = append(fileList, p)
+		}
+	}
+
+	problems := make([]NoteProblem, 0)
+	for _, fileName := range fileList {
+		f, err := os.Open(fileName)
+		if err != nil {
+			problems = append(problems, NoteProblem{Path: fileName, Message: fmt.Sprintf("could not open file: %v", err)})
+			continue
+		}
+		if _, ok := formatFor(fileName).(txtFormat); ok {
+			problems = append(problems, CheckNote(f, fileName)...)
+		} else {
+			problems = append(problems, checkParsedNote(f, fileName)...)
+		}
+		f.Close()
+	}
+	return problems, nil
+}
+
+var checkCmd = &cobra.Command{
+	Use:           "check",
+	Short:         "validates the headers of notes",
+	Long:          "validates the headers of notes, reporting each problem with its file and line. exits non-zero if any problems were found. paths are relative to the notebook and directories check every note in them, if no path is specified the whole notebook is checked.",
+	Example:       "notes check [path...]",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(_ *cobra.Command, args []string) error {
+		fileList, err := noteArgs(args)
+		if err != nil {
+			return err
+		}
+		problems, err := CheckNotes(fileList)
+		if err != nil {
+			return err
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("found %v problem(s)", len(problems))
+		}
+		return nil
+	},
+}
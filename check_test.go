@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckNote(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		want     []NoteProblem
+	}{
+		{
+			name:     "valid note",
+			fileName: "basic.txt",
+			want:     []NoteProblem{},
+		},
+		{
+			name:     "empty header",
+			fileName: "bad_header_01.txt",
+			want: []NoteProblem{
+				{Line: 1, Message: "empty header"},
+			},
+		},
+		{
+			name:     "invalid header field",
+			fileName: "bad_header_02.txt",
+			want: []NoteProblem{
+				{Line: 2, Message: "could not parse header line: boop"},
+			},
+		},
+		{
+			name:     "duplicate tags, empty field name and missing title",
+			fileName: "check_problems.txt",
+			want: []NoteProblem{
+				{Line: 1, Message: `duplicate tag "one"`},
+				{Line: 1, Message: `duplicate tag "two"`},
+				{Line: 3, Message: "empty header field name"},
+				{Line: 0, Message: "missing title"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := joinPath(tt.fileName)
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("could not open test note: %v", err)
+			}
+			defer file.Close()
+
+			for i := range tt.want {
+				tt.want[i].Path = path
+			}
+			got := CheckNote(file, path)
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("problem mismatch:\nexpected: %v\ngot: %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckEditedNotes(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ROOT_ENV, root)
+	for _, name := range []string{"a.txt", "b.md"} {
+		filePath := filepath.Join(root, name)
+		if err := NewNoteFile(filePath, NewNoteOptions{Title: "edited", Tags: []string{"work"}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, field := range [][2]string{{"status", "open"}, {"due", "2026-11-01"}, {"author", "Sam"}} {
+			if err := SetNoteField(filePath, field[0], field[1]); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	problems, err := CheckNotes([]string{root})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("expected notes edited with meta set to pass, got %v", problems)
+	}
+}
+
+func TestCheckCommandPaths(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ROOT_ENV, root)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0770); err != nil {
+		t.Fatalf("could not create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("title: a\n------\n"), 0660); err != nil {
+		t.Fatalf("could not write test note: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "sub", "b.txt"), []byte("title: b\nentry-order: sideways\n------\n"), 0660); err != nil {
+		t.Fatalf("could not write test note: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		wantProblems bool
+	}{
+		{name: "note relative to the notebook", args: []string{"a.txt"}},
+		{name: "note without its extension", args: []string{"a"}},
+		{name: "directory relative to the notebook", args: []string{"sub"}, wantProblems: true},
+		{name: "whole notebook", wantProblems: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runCommand(t, append([]string{"check"}, tt.args...)...)
+			if tt.wantProblems != (err != nil) {
+				t.Fatalf("expected problems: %v, got %v %q", tt.wantProblems, err, out)
+			}
+			if tt.wantProblems && !strings.Contains(out, filepath.Join(root, "sub", "b.txt")+":2: unknown entry order `sideways`") {
+				t.Errorf("expected the problem in sub/b.txt to be reported, got %q", out)
+			}
+		})
+	}
+	if _, err := runCommand(t, "check", "missing"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a missing note to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+)
+
+const CONFIG_FILE = "config.toml"
+const ROOT_ENV = "NOTES_DIR"
+
+type Config struct {
+	// directory every note path is resolved against, defaults to the working directory
+	Root       string `toml:"root"`
+	DateFormat string `toml:"date_format"`
+	// date, datetime, week or a go time layout, see formatEntryStamp
+	EntryFormat string `toml:"entry_format"`
+	Divider     string `toml:"divider"`
+	Extension   string `toml:"extension"`
+	// falls back to $VISUAL and then $EDITOR when empty
+	Editor string `toml:"editor"`
+	// path of the journal note relative to the notebook, the parts in braces are go time layouts filled in with the day
+	Journal string `toml:"journal"`
+	// daily for a note per day or rolling for a single note with an entry per day
+	JournalMode string `toml:"journal_mode"`
+	// filled into templates, falls back to the logged in user
+	Author string `toml:"author"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		DateFormat:  JOURNAL_DATE_FORMAT,
+		EntryFormat: ENTRY_DATE,
+		Divider:     DIVIDER,
+		Extension:   ".txt",
+		Journal:     DEFAULT_JOURNAL,
+		JournalMode: JOURNAL_DAILY,
+	}
+}
+
+func (c *Config) validate() error {
+	if len(strings.TrimSpace(c.Divider)) == 0 {
+		return fmt.Errorf("divider can not be empty")
+	}
+	if len(c.DateFormat) == 0 {
+		return fmt.Errorf("date_format can not be empty")
+	}
+	if len(strings.TrimSpace(c.EntryFormat)) == 0 {
+		return fmt.Errorf("entry_format can not be empty")
+	}
+	if len(c.Extension) == 0 || c.Extension == "." {
+		return fmt.Errorf("extension can not be empty")
+	}
+	if len(strings.TrimSpace(c.Journal)) == 0 {
+		return fmt.Errorf("journal can not be empty")
+	}
+	if c.JournalMode != JOURNAL_DAILY && c.JournalMode != JOURNAL_ROLLING {
+		return fmt.Errorf("journal_mode has to be %v or %v", JOURNAL_DAILY, JOURNAL_ROLLING)
+	}
+	if _, err := journalPath(c.Journal, time.Now()); err != nil {
+		return err
+	}
+	if c.JournalMode == JOURNAL_DAILY && !strings.Contains(c.Journal, "{") {
+		return fmt.Errorf("journal needs a date in braces, e.g. %v, for journal_mode %v to give every day its own note", DEFAULT_JOURNAL, JOURNAL_DAILY)
+	}
+	if !strings.HasPrefix(c.Extension, ".") {
+		c.Extension = "." + c.Extension
+	}
+	return nil
+}
+
+type configKey struct {
+	name        string
+	description string
+	field       func(c *Config) *string
+}
+
+var configKeys = []configKey{
+	{"root", "directory of the notebook, only read from the user config", func(c *Config) *string { return &c.Root }},
+	{"date_format", "go time layout used for journal entries", func(c *Config) *string { return &c.DateFormat }},
+	{"entry_format", "how journal entries are stamped: date, datetime, week or a go time layout", func(c *Config) *string { return &c.EntryFormat }},
+	{"divider", "line separating a note's header from its content", func(c *Config) *string { return &c.Divider }},
+	{"extension", "file extension of notes", func(c *Config) *string { return &c.Extension }},
+	{"editor", "command used to edit notes, defaults to $VISUAL or $EDITOR", func(c *Config) *string { return &c.Editor }},
+	{"journal", "path of the journal, the parts in braces are go time layouts filled in with the day, e.g. {2006-01-02}", func(c *Config) *string { return &c.Journal }},
+	{"journal_mode", "daily for a note per day, rolling for one note with an entry per day", func(c *Config) *string { return &c.JournalMode }},
+	{"author", "name filled into templates, defaults to the logged in user", func(c *Config) *string { return &c.Author }},
+}
+
+func findConfigKey(name string) (configKey, error) {
+	for _, key := range configKeys {
+		if key.name == name {
+			return key, nil
+		}
+	}
+	return configKey{}, fmt.Errorf("unknown config key `%v`", name)
+}
+
+var (
+	// set by the --root flag, takes priority over everything else
+	rootFlag string
+
+	// the defaults with the user's config and then the notebook's config layered on top, see loadSettings
+	settings = defaultConfig()
+)
+
+// configDir follows XDG, falling back to ~/.config on every platform so the config is easy to find
+func configDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, "notes"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "notes"), nil
+}
+
+func userConfigPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CONFIG_FILE), nil
+}
+
+func notebookConfigPath(root string) string {
+	return filepath.Join(root, METADATA_DIR, CONFIG_FILE)
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// decodeConfigFile layers the toml file at path on top of cfg, a missing file is not an error
+func decodeConfigFile(path string, cfg *Config) error {
+	meta, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read config (at path: %v): %w", path, err)
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown config key `%v` (at path: %v), remove it with: notes config set %v \"\"", undecoded[0], path, undecoded[0])
+	}
+	return nil
+}
+
+// loadSettings reads the user's config and then the config of the notebook it points at
+func loadSettings() error {
+	cfg := defaultConfig()
+	userPath, err := userConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := decodeConfigFile(userPath, &cfg); err != nil {
+		return err
+	}
+	settings = cfg
+
+	root, err := notesRoot()
+	if err != nil {
+		return err
+	}
+	if err := decodeConfigFile(notebookConfigPath(root), &cfg); err != nil {
+		return err
+	}
+	// moving the notebook from inside the notebook doesn't make sense
+	cfg.Root = settings.Root
+	if err := cfg.validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	settings = cfg
+	return nil
+}
+
+// set when the config couldn't be loaded, see requireConfig
+var configErr error
+
+// initConfig loads the settings before any command runs. a broken config only stops commands once they need it,
+// so that the config commands can still be used to fix it
+func initConfig() {
+	configErr = loadSettings()
+}
+
+// requireConfig stops the command if the config couldn't be loaded
+func requireConfig() {
+	if configErr != nil {
+		fmt.Println(configErr)
+		os.Exit(1)
+	}
+}
+
+// isConfigCommand checks whether cmd is one of the config commands, which work even if the config is broken
+func isConfigCommand(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd == configCmd {
+			return true
+		}
+	}
+	return false
+}
+
+// notesRoot works out which directory the notebook lives in, in order of priority:
+// the --root flag, the NOTES_DIR environment variable, the config file and finally the working directory
+func notesRoot() (string, error) {
+	root := rootFlag
+	if len(root) == 0 {
+		root = os.Getenv(ROOT_ENV)
+	}
+	if len(root) == 0 {
+		root = settings.Root
+	}
+	if len(root) == 0 {
+		curDir, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("could not get working directory: %w", err)
+		}
+		return curDir, nil
+	}
+
+	root, err := expandHome(root)
+	if err != nil {
+		return "", err
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve notes root: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", fmt.Errorf("could not use notes root: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("notes root `%v` is not a directory", root)
+	}
+	return root, nil
+}
+
+// SetConfigValue changes a single key in the config file at path, leaving the rest of the file's keys alone
+func SetConfigValue(path, name, value string) error {
+	values := make(map[string]interface{})
+	if _, err := toml.DecodeFile(path, &values); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not read config (at path: %v): %w", path, err)
+	}
+	key, err := findConfigKey(name)
+	// unknown keys can still be removed, that's how a mistyped key gets fixed
+	if _, set := values[name]; err != nil && !(set && len(value) == 0) {
+		return err
+	}
+	if len(value) == 0 {
+		delete(values, name)
+	} else {
+		check := defaultConfig()
+		*key.field(&check) = value
+		if err := check.validate(); err != nil {
+			return fmt.Errorf("invalid value for %v: %w", name, err)
+		}
+		values[name] = *key.field(&check)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+	var out strings.Builder
+	if err := toml.NewEncoder(&out).Encode(values); err != nil {
+		return fmt.Errorf("could not encode config: %w", err)
+	}
+	if err := writeFileAtomic(path, []byte(out.String()), 0666); err != nil {
+		return fmt.Errorf("could not write config (at path: %v): %w", path, err)
+	}
+	return nil
+}
+
+var configNotebook bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "inspects and changes settings",
+	Long:  "inspects and changes settings. settings are read from ~/.config/notes/" + CONFIG_FILE + " and then from " + METADATA_DIR + "/" + CONFIG_FILE + " in the notebook, which takes priority.",
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "lists every setting and its current value",
+	Args:  cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		for _, key := range configKeys {
+			fmt.Printf("%v = %q\t# %v\n", key.name, *key.field(&settings), key.description)
+		}
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:     "get",
+	Short:   "outputs the current value of a setting",
+	Example: "notes config get date_format",
+	Args:    cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		key, err := findConfigKey(args[0])
+		if err != nil {
+			fmt.Printf("Problem trying to get setting: %v", err)
+			return
+		}
+		fmt.Println(*key.field(&settings))
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:     "set",
+	Short:   "changes a setting in the user's config, or the notebook's config with --notebook",
+	Long:    "changes a setting in the user's config, or the notebook's config with --notebook. setting an empty value removes the setting so the default is used again, which also works for keys notes doesn't know.",
+	Example: "notes config set [--notebook] date_format \"Monday, 2006-01-02\"",
+	Args:    cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		var path string
+		if configNotebook {
+			root, err := notesRoot()
+			if err != nil {
+				fmt.Printf("Problem trying to set setting: %v", err)
+				return
+			}
+			path = notebookConfigPath(root)
+		} else {
+			userPath, err := userConfigPath()
+			if err != nil {
+				fmt.Printf("Problem trying to set setting: %v", err)
+				return
+			}
+			path = userPath
+		}
+		if err := SetConfigValue(path, args[0], args[1]); err != nil {
+			fmt.Printf("Problem trying to set setting: %v", err)
+			return
+		}
+		fmt.Printf("Set %v in %v\n", args[0], path)
+	},
+}
+
+func init() {
+	configSetCmd.Flags().BoolVar(&configNotebook, "notebook", false, "change the notebook's config instead of the user's")
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSettings(t *testing.T) {
+	userDir := t.TempDir()
+	notebook := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv(ROOT_ENV, "")
+	defer func() { settings = defaultConfig() }()
+
+	userConfig := filepath.Join(userDir, "notes", CONFIG_FILE)
+	if err := SetConfigValue(userConfig, "root", notebook); err != nil {
+		t.Fatalf("could not set root: %v", err)
+	}
+	if err := SetConfigValue(userConfig, "date_format", "Jan 2 2006"); err != nil {
+		t.Fatalf("could not set date_format: %v", err)
+	}
+	if err := SetConfigValue(userConfig, "divider", "~~~"); err != nil {
+		t.Fatalf("could not set divider: %v", err)
+	}
+	if err := SetConfigValue(notebookConfigPath(notebook), "extension", "md"); err != nil {
+		t.Fatalf("could not set extension: %v", err)
+	}
+	if err := SetConfigValue(notebookConfigPath(notebook), "divider", "==="); err != nil {
+		t.Fatalf("could not set divider: %v", err)
+	}
+	if err := SetConfigValue(userConfig, "bogus", "value"); err == nil {
+		t.Errorf("expected an error setting an unknown key")
+	}
+
+	if err := loadSettings(); err != nil {
+		t.Fatalf("could not load settings: %v", err)
+	}
+	want := Config{
+		Root:        notebook,
+		DateFormat:  "Jan 2 2006",
+		EntryFormat: ENTRY_DATE,
+		Divider:     "===",
+		Extension:   ".md",
+		Journal:     DEFAULT_JOURNAL,
+		JournalMode: JOURNAL_DAILY,
+	}
+	if settings != want {
+		t.Errorf("settings mismatch:\nexpected: %+v\ngot: %+v", want, settings)
+	}
+
+	if err := SetConfigValue(notebookConfigPath(notebook), "divider", ""); err != nil {
+		t.Fatalf("could not unset divider: %v", err)
+	}
+	if err := loadSettings(); err != nil {
+		t.Fatalf("could not load settings: %v", err)
+	}
+	if settings.Divider != "~~~" {
+		t.Errorf("expected unsetting the notebook divider to fall back to the user's, got %v", settings.Divider)
+	}
+
+	if err := os.WriteFile(userConfig, []byte("dividr = \"typo\"\n"), 0660); err != nil {
+		t.Fatalf("could not write config: %v", err)
+	}
+	if err := loadSettings(); err == nil {
+		t.Errorf("expected an error loading a config with an unknown key")
+	}
+}
+
+func TestBrokenConfig(t *testing.T) {
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	t.Setenv(ROOT_ENV, t.TempDir())
+	defer func() { settings = defaultConfig(); configErr = nil }()
+
+	userConfig := filepath.Join(userDir, "notes", CONFIG_FILE)
+	if err := os.MkdirAll(filepath.Dir(userConfig), 0770); err != nil {
+		t.Fatalf("could not create config directory: %v", err)
+	}
+	if err := os.WriteFile(userConfig, []byte("date_formt = \"2006\"\n"), 0660); err != nil {
+		t.Fatalf("could not write config: %v", err)
+	}
+	if err := loadSettings(); err == nil {
+		t.Fatalf("expected an error loading a config with an unknown key")
+	}
+
+	// the config commands still run, so the mistyped key can be removed
+	if _, err := runCommand(t, "config", "set", "date_formt", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := loadSettings(); err != nil {
+		t.Errorf("expected the config to be fixed, got %v", err)
+	}
+	if err := SetConfigValue(userConfig, "date_formt", ""); err == nil {
+		t.Errorf("expected an error removing a key that isn't set")
+	}
+}
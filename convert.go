@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Conversion is a note rewritten in another format. Lost is whatever couldn't be carried over,
+// Problem is why the note couldn't be converted at all
+type Conversion struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Lost    []string `json:"lost"`
+	Problem string   `json:"problem,omitempty"`
+	data    []byte
+	perm    os.FileMode
+}
+
+// convertFields works out which header fields survive being written in the to format, and what's lost on the way
+func convertFields(fields Fields, to NoteFormat) (Fields, []string) {
+	results := make(Fields, 0, len(fields))
+	lost := make([]string, 0)
+	_, toTxt := to.(txtFormat)
+	for i, field := range fields {
+		if fields.lastIndex(field.Key) != i {
+			lost = append(lost, fmt.Sprintf("field `%v` is repeated, only the last value is kept", field.Key))
+			continue
+		}
+		if toTxt && strings.ContainsAny(field.Value, "\r\n") {
+			lines := strings.FieldsFunc(field.Value, func(r rune) bool { return r == '\r' || r == '\n' })
+			for j := range lines {
+				lines[j] = strings.TrimSpace(lines[j])
+			}
+			field.Value = strings.Join(lines, " ")
+			lost = append(lost, fmt.Sprintf("value of `%v` spans several lines, they're joined into one", field.Key))
+		}
+		if toTxt {
+			if err := validateHeaderField(field.Key, field.Value); err != nil {
+				lost = append(lost, fmt.Sprintf("field `%v` is dropped: %v", field.Key, err))
+				continue
+			}
+		}
+		results = append(results, field)
+	}
+	return results, lost
+}
+
+// ConvertNote works out what the note at filePath looks like in the to format, the content is kept as is
+func ConvertNote(filePath string, to NoteFormat) (Conversion, error) {
+	from := formatFor(filePath)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return Conversion{}, fmt.Errorf("could not stat file: %v, %w", filePath, err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return Conversion{}, fmt.Errorf("could not read file: %v, %w", filePath, err)
+	}
+	note, err := from.Parse(bytes.NewReader(data), filePath, true)
+	if err != nil {
+		return Conversion{}, fmt.Errorf("only notes with a header can be converted: %w", err)
+	}
+	_, content, _ := from.Split(data)
+
+	lost := make([]string, 0)
+	if _, fromMarkdown := from.(markdownFormat); fromMarkdown {
+		lost = append(lost, frontMatterLosses(note.rawHeader)...)
+	}
+	fields, fieldsLost := convertFields(note.Fields, to)
+	lost = append(lost, fieldsLost...)
+	out, err := to.Render(fields, string(content))
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	ext, _ := noteExtension(filePath)
+	target := strings.TrimSuffix(filePath, ext) + to.Extensions()[0]
+	if exists(target) {
+		return Conversion{}, fmt.Errorf("file `%v` already exists", target)
+	}
+	if _, err := to.Parse(bytes.NewReader(out), target, true); err != nil {
+		return Conversion{}, fmt.Errorf("converted note would not be valid: %w", err)
+	}
+	return Conversion{From: filePath, To: target, Lost: lost, data: out, perm: info.Mode().Perm()}, nil
+}
+
+// ConvertNotes works out the conversion of every note in fileList that isn't already in the to format
+func ConvertNotes(fileList []string, to NoteFormat) []Conversion {
+	sort.Strings(fileList)
+	results := make([]Conversion, 0)
+	for _, filePath := range fileList {
+		if formatFor(filePath).Name() == to.Name() {
+			continue
+		}
+		conversion, err := ConvertNote(filePath, to)
+		if err != nil {
+			conversion = Conversion{From: filePath, Lost: []string{}, Problem: err.Error()}
+		}
+		results = append(results, conversion)
+	}
+	return results
+}
+
+// applyConversion writes the converted note and removes the original, which is kept as a backup
+func applyConversion(conversion Conversion) error {
+	if err := backupNote(conversion.From); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(conversion.To, conversion.data, conversion.perm); err != nil {
+		return fmt.Errorf("could not write file: %v, %w", conversion.To, err)
+	}
+	// it's the same note under a new name, so it keeps exactly the permissions it had rather than losing the umask
+	if err := os.Chmod(conversion.To, conversion.perm); err != nil {
+		return fmt.Errorf("could not set permissions of file: %v, %w", conversion.To, err)
+	}
+	if err := os.Remove(conversion.From); err != nil {
+		return fmt.Errorf("could not remove file: %v, %w", conversion.From, err)
+	}
+	return nil
+}
+
+var (
+	convertTo     string
+	convertDryRun bool
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "rewrites notes in another format",
+	Long: `rewrites notes in another format, txt notes with a header and divider or md notes with yaml front matter.
+every header field is carried over and the content is kept as is, anything that can't be carried over is reported.
+the original of each converted note is kept as a backup. with no paths every note in the notebook is converted,
+directories convert every note in them`,
+	Example: `notes convert --to md [--dry-run] [paths...]
+notes convert --to txt work/q3.md`,
+	Run: func(_ *cobra.Command, args []string) {
+		to, err := findFormat(convertTo)
+		if err != nil {
+			fmt.Printf("Problem trying to convert: %v", err)
+			return
+		}
+		fileList, err := noteArgs(args)
+		if err != nil {
+			fmt.Printf("Problem trying to convert: %v", err)
+			return
+		}
+		conversions := ConvertNotes(fileList, to)
+		for i, conversion := range conversions {
+			if len(conversion.Problem) > 0 || convertDryRun {
+				continue
+			}
+			if err := applyConversion(conversion); err != nil {
+				conversions[i].Problem = err.Error()
+			}
+		}
+
+		err = writeOutput(conversions, func(conversion Conversion) {
+			switch {
+			case len(conversion.Problem) > 0:
+				fmt.Printf("Could not convert %v: %v\n", conversion.From, conversion.Problem)
+				return
+			case convertDryRun:
+				fmt.Printf("Would convert %v to %v\n", conversion.From, conversion.To)
+			default:
+				fmt.Printf("Converted %v to %v\n", conversion.From, conversion.To)
+			}
+			for _, lost := range conversion.Lost {
+				fmt.Printf("  lost: %v\n", lost)
+			}
+		})
+		if err != nil {
+			fmt.Printf("Problem trying to output conversions: %v", err)
+			return
+		}
+		if len(conversions) == 0 && outputFormat == OUTPUT_TEXT {
+			fmt.Printf("No notes needed converting to %v\n", to.Name())
+		}
+	},
+}
+
+func init() {
+	convertCmd.Flags().StringVar(&convertTo, "to", "", "format to convert to: md or txt")
+	convertCmd.Flags().BoolVar(&convertDryRun, "dry-run", false, "report what would change without writing anything")
+	_ = convertCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConvertNote(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0660); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+		return path
+	}
+	plan := write("plan.txt", "title: Plan\ntags: a, b\nowner: me\nOwner: you\n------\n\n2026-10-01:\nhello\n")
+	idea := write("idea.md", "---\n# draft\ntitle: Idea\npeople: [a, b]\ndesc: |\n  one\n  two\n---\nbody\n")
+	taken := write("taken.txt", "title: taken\n------\n")
+	write("taken.md", "---\ntitle: taken\n---\n")
+	readme := write("README.txt", "no header here\n")
+
+	md, err := findFormat("md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txt, err := findFormat("TXT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := findFormat("docx"); err == nil {
+		t.Errorf("expected an unknown format to be rejected")
+	}
+
+	toMarkdown, err := ConvertNote(plan, md)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, "plan.md"); toMarkdown.To != want {
+		t.Errorf("expected target %v, got %v", want, toMarkdown.To)
+	}
+	if want := "---\ntitle: Plan\ntags: [a, b]\nOwner: you\n---\n\n2026-10-01:\nhello\n"; string(toMarkdown.data) != want {
+		t.Errorf("markdown mismatch:\nexpected: %q\ngot: %q", want, string(toMarkdown.data))
+	}
+	if want := []string{"field `owner` is repeated, only the last value is kept"}; !reflect.DeepEqual(want, toMarkdown.Lost) {
+		t.Errorf("lost mismatch:\nexpected: %v\ngot: %v", want, toMarkdown.Lost)
+	}
+
+	toTxt, err := ConvertNote(idea, txt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "title: Idea\npeople: a, b\ndesc: one two\n------\nbody\n"; string(toTxt.data) != want {
+		t.Errorf("txt mismatch:\nexpected: %q\ngot: %q", want, string(toTxt.data))
+	}
+	wantLost := []string{
+		"comments in the front matter are dropped",
+		"list `people` becomes comma separated text",
+		"value of `desc` spans several lines, they're joined into one",
+	}
+	if !reflect.DeepEqual(wantLost, toTxt.Lost) {
+		t.Errorf("lost mismatch:\nexpected: %v\ngot: %v", wantLost, toTxt.Lost)
+	}
+
+	if _, err := ConvertNote(taken, md); err == nil {
+		t.Errorf("expected an error converting onto an existing note")
+	}
+	if _, err := ConvertNote(readme, md); err == nil {
+		t.Errorf("expected an error converting a note without a header")
+	}
+
+	conversions := ConvertNotes([]string{plan, idea, readme}, md)
+	if len(conversions) != 2 || conversions[0].From != readme || len(conversions[0].Problem) == 0 || conversions[1].From != plan {
+		t.Errorf("expected the readme to fail and the markdown note to be left out, got %+v", conversions)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// editors that can be told which line to open on with +line
+var plusLineEditors = map[string]bool{
+	"vi":          true,
+	"vim":         true,
+	"nvim":        true,
+	"nano":        true,
+	"emacs":       true,
+	"emacsclient": true,
+	"micro":       true,
+	"kak":         true,
+}
+
+// editorCommand works out what to run to edit a note, in order of priority: the editor setting, $VISUAL, $EDITOR and finally vi
+func editorCommand() []string {
+	for _, editor := range []string{settings.Editor, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if fields := strings.Fields(editor); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+// editorArgs adds the file to the editor's command, positioned at line for the editors that support it
+func editorArgs(command []string, filePath string, line int) []string {
+	args := append([]string{}, command[1:]...)
+	if line <= 0 {
+		return append(args, filePath)
+	}
+	switch name := filepath.Base(command[0]); {
+	case plusLineEditors[name]:
+		return append(args, fmt.Sprintf("+%v", line), filePath)
+	case name == "code" || name == "codium":
+		return append(args, "--goto", fmt.Sprintf("%v:%v", filePath, line))
+	case name == "subl" || name == "hx":
+		return append(args, fmt.Sprintf("%v:%v", filePath, line))
+	}
+	return append(args, filePath)
+}
+
+// newestEntryLine finds the line of the most recent entry added by addTimestamp, wherever the note keeps it.
+// returns 0 if the note has no entries
+func newestEntryLine(note *Note) int {
+	line := 0
+	var newest time.Time
+	for _, entry := range ParseEntries(*note) {
+		if line == 0 || entry.Time.After(newest) {
+			line = entry.Line
+			newest = entry.Time
+		}
+	}
+	return line
+}
+
+// EditNote opens the note in the editor at its newest entry
+func EditNote(filePath string) error {
+	line := 0
+	if file, err := os.Open(filePath); err == nil {
+		if note, err := parseNote(file, filePath, false); err == nil {
+			line = newestEntryLine(note)
+		}
+		file.Close()
+	}
+	return EditNoteAt(filePath, line)
+}
+
+// EditNoteAt opens the note in the editor at line, 0 leaves it up to the editor
+func EditNoteAt(filePath string, line int) error {
+	// files that were read as untitled notes didn't have a header to break
+	hadHeader := false
+	if note, err := parseNoteFile(filePath, true); err == nil {
+		hadHeader = note.headerErr == nil
+	}
+
+	command := editorCommand()
+	editor := exec.Command(command[0], editorArgs(command, filePath, line)...)
+	editor.Stdin = os.Stdin
+	editor.Stdout = os.Stdout
+	editor.Stderr = os.Stderr
+	if err := editor.Run(); err != nil {
+		return fmt.Errorf("problem running editor `%v`: %w", command[0], err)
+	}
+	if !hadHeader && !strictParse {
+		return nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("could not open file: %v, %w", filePath, err)
+	}
+	defer file.Close()
+	if _, err := parseNote(file, filePath, true); err != nil {
+		return fmt.Errorf("note header is no longer valid: %w", err)
+	}
+	return nil
+}
+
+var editCmd = &cobra.Command{
+	Use:     "edit",
+	Aliases: []string{"ed"},
+	Short:   "opens a note in your editor",
+	Long:    "opens a note in the configured editor, $VISUAL or $EDITOR, at the newest entry if the editor supports it. the header is checked once the editor exits. if no note is specified, it goes into an interactive mode to select one.",
+	Example: "notes edit [directory/file]",
+	Args:    optionalExistingNote,
+	Run: func(_ *cobra.Command, args []string) {
+		var selectedFile string
+		if len(args) == 0 {
+			choice, err := SelectNote("Select File to Edit", true)
+			if err != nil {
+				fmt.Printf("Could not select a file: %v", err)
+				return
+			}
+			selectedFile = choice.Path
+		} else {
+			selectedFile = args[0]
+		}
+		if err := EditNote(selectedFile); err != nil {
+			fmt.Printf("Problem trying to edit: %v", err)
+		}
+	},
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditorArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		line    int
+		want    []string
+	}{
+		{name: "vim", command: []string{"vim"}, line: 4, want: []string{"+4", "note.txt"}},
+		{name: "nvim with a path and flags", command: []string{"/usr/bin/nvim", "-u", "NONE"}, line: 4, want: []string{"-u", "NONE", "+4", "note.txt"}},
+		{name: "nano", command: []string{"nano"}, line: 12, want: []string{"+12", "note.txt"}},
+		{name: "emacs", command: []string{"emacs", "-nw"}, line: 1, want: []string{"-nw", "+1", "note.txt"}},
+		{name: "code", command: []string{"code", "--wait"}, line: 4, want: []string{"--wait", "--goto", "note.txt:4"}},
+		{name: "helix", command: []string{"hx"}, line: 4, want: []string{"note.txt:4"}},
+		{name: "no line support", command: []string{"gedit"}, line: 4, want: []string{"note.txt"}},
+		{name: "no line", command: []string{"vim"}, line: 0, want: []string{"note.txt"}},
+	}
+	for _, tt := range tests {
+		if got := editorArgs(tt.command, "note.txt", tt.line); !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("%v: args mismatch:\nexpected: %q\ngot: %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestNewestEntryLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		contents string
+		want     int
+	}{
+		{
+			name:     "no entries",
+			path:     "plain.txt",
+			contents: "title: plain\n------\njust some text\n",
+			want:     0,
+		},
+		{
+			name:     "prepend",
+			path:     "prepend.txt",
+			contents: "title: prepend\n------\n2026-01-02:\nnewer\n\n2026-01-01:\nolder\n",
+			want:     3,
+		},
+		{
+			name:     "append",
+			path:     "append.txt",
+			contents: "title: append\nentry-order: append\n------\n2026-01-01:\nolder\n\n2026-01-02:\nnewer\n",
+			want:     7,
+		},
+		{
+			name:     "append in markdown",
+			path:     "append.md",
+			contents: "---\ntitle: append\nentry-order: append\n---\n2026-01-01 09:00:\nmorning\n\n2026-01-01 17:30:\nevening\n",
+			want:     8,
+		},
+	}
+	for _, tt := range tests {
+		note, err := parseNote(strings.NewReader(tt.contents), tt.path, false)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.name, err)
+		}
+		if got := newestEntryLine(note); got != tt.want {
+			t.Errorf("%v: expected line %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// the named entry formats, anything else is used as a go time layout
+const (
+	ENTRY_DATE     = "date"
+	ENTRY_DATETIME = "datetime"
+	ENTRY_WEEK     = "week"
+)
+
+// header field picking where new entries go in a note, overridden by entry --order
+const ENTRY_ORDER_FIELD = "entry-order"
+
+const (
+	ENTRY_PREPEND = "prepend"
+	ENTRY_APPEND  = "append"
+)
+
+// EntryOptions control what gets written for a new journal entry
+type EntryOptions struct {
+	// one of the named entry formats or a go time layout, defaults to the entry_format setting
+	Format string
+	// written on the same line as the stamp
+	Heading string
+	// the body of the entry, left blank to be filled in later when empty
+	Message string
+	// prepend or append, defaults to the note's entry-order field and then prepend
+	Order string
+	// don't add another heading when the note already has an entry with the same stamp
+	SkipExisting bool
+}
+
+// entryOrder works out where entries go in a note with the given header fields, a flag wins over the header field
+func entryOrder(fields Fields, order string) (string, error) {
+	if len(order) == 0 {
+		order, _ = fields.Get(ENTRY_ORDER_FIELD)
+	}
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "", ENTRY_PREPEND:
+		return ENTRY_PREPEND, nil
+	case ENTRY_APPEND:
+		return ENTRY_APPEND, nil
+	}
+	return "", fmt.Errorf("unknown entry order `%v`, expected %v or %v", order, ENTRY_PREPEND, ENTRY_APPEND)
+}
+
+// findEntry returns the index of the line starting the entry stamped with stamp, or -1
+func findEntry(lines []string, stamp string) int {
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line == stamp+":" || strings.HasPrefix(line, stamp+": ") {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatEntryStamp formats ts for an entry. date and datetime follow the date_format setting and week is the ISO week
+func formatEntryStamp(ts time.Time, format string) string {
+	switch format {
+	case "", ENTRY_DATE:
+		return ts.Format(settings.DateFormat)
+	case ENTRY_DATETIME:
+		return ts.Format(settings.DateFormat + " 15:04")
+	case ENTRY_WEEK:
+		year, week := ts.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return ts.Format(format)
+}
+
+// formatEntry is the text of a new entry, ending with a blank line to separate it from whatever follows
+func formatEntry(ts time.Time, opts EntryOptions) string {
+	format := opts.Format
+	if len(format) == 0 {
+		format = settings.EntryFormat
+	}
+	line := formatEntryStamp(ts, format) + ":"
+	if heading := strings.TrimSpace(opts.Heading); len(heading) > 0 {
+		line += " " + heading
+	}
+	message := strings.Trim(opts.Message, "\r\n")
+	return fmt.Sprintf("%v\n%v\n\n", line, message)
+}
+
+// addEntry adds an entry to the content of a note, at the top or the bottom depending on order.
+// with SkipExisting, an entry with the same stamp gets the message instead of a new heading being added,
+// which is reported through existing
+func addEntry(content string, ts time.Time, order string, opts EntryOptions) (out string, existing bool) {
+	format := opts.Format
+	if len(format) == 0 {
+		format = settings.EntryFormat
+	}
+	// the blank line after the divider gets written back with the header, so it shouldn't pile up
+	content = strings.TrimLeft(content, "\r\n")
+	if opts.SkipExisting {
+		lines := strings.Split(content, "\n")
+		if i := findEntry(lines, formatEntryStamp(ts, format)); i >= 0 {
+			message := strings.Trim(opts.Message, "\r\n")
+			if len(message) == 0 {
+				return content, true
+			}
+			// the newest entry is the first one when prepending and the last one when appending,
+			// so the message goes where the rest of the entry's newest text would be
+			if order == ENTRY_APPEND {
+				return strings.TrimRight(content, "\r\n") + "\n" + message + "\n", true
+			}
+			lines = append(lines[:i+1], append([]string{message}, lines[i+1:]...)...)
+			return strings.Join(lines, "\n"), true
+		}
+	}
+
+	if order == ENTRY_APPEND {
+		if len(strings.TrimSpace(content)) == 0 {
+			return formatEntry(ts, opts), false
+		}
+		return strings.TrimRight(content, "\r\n") + "\n\n" + formatEntry(ts, opts), false
+	}
+	return formatEntry(ts, opts) + content, false
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatEntry(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 9, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts EntryOptions
+		want string
+	}{
+		{name: "default", opts: EntryOptions{}, want: "2026-01-01:\n\n\n"},
+		{name: "datetime", opts: EntryOptions{Format: ENTRY_DATETIME}, want: "2026-01-01 09:05:\n\n\n"},
+		{name: "week", opts: EntryOptions{Format: ENTRY_WEEK}, want: "2026-W01:\n\n\n"},
+		{name: "layout", opts: EntryOptions{Format: "Jan 2 15:04"}, want: "Jan 1 09:05:\n\n\n"},
+		{
+			name: "heading and message",
+			opts: EntryOptions{Format: ENTRY_DATETIME, Heading: " standup ", Message: "moved the release\nto friday\n"},
+			want: "2026-01-01 09:05: standup\nmoved the release\nto friday\n\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := formatEntry(ts, tt.opts); got != tt.want {
+			t.Errorf("%v: entry mismatch:\nexpected: %q\ngot: %q", tt.name, tt.want, got)
+		}
+	}
+
+	// the first of january 2027 is still in the last week of 2026
+	if got := formatEntryStamp(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), ENTRY_WEEK); got != "2026-W53" {
+		t.Errorf("expected the ISO week's year to be used, got %v", got)
+	}
+}
+
+func TestAddEntry(t *testing.T) {
+	ts := time.Date(2026, 1, 1, 9, 5, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		content      string
+		order        string
+		opts         EntryOptions
+		want         string
+		wantExisting bool
+	}{
+		{
+			name:    "prepend",
+			content: "\nolder\n",
+			order:   ENTRY_PREPEND,
+			opts:    EntryOptions{Message: "newer"},
+			want:    "2026-01-01:\nnewer\n\nolder\n",
+		},
+		{
+			name:    "append",
+			content: "\nolder\n\n\n",
+			order:   ENTRY_APPEND,
+			opts:    EntryOptions{Message: "newer"},
+			want:    "older\n\n2026-01-01:\nnewer\n\n",
+		},
+		{
+			name:    "append to empty note",
+			content: "\n",
+			order:   ENTRY_APPEND,
+			want:    "2026-01-01:\n\n\n",
+		},
+		{
+			name:         "skip existing heading",
+			content:      "2026-01-01:\nearlier\n",
+			order:        ENTRY_PREPEND,
+			opts:         EntryOptions{SkipExisting: true},
+			want:         "2026-01-01:\nearlier\n",
+			wantExisting: true,
+		},
+		{
+			name:         "message under existing prepended heading",
+			content:      "2026-01-01: standup\nearlier\n\n2025-12-31:\nolder\n",
+			order:        ENTRY_PREPEND,
+			opts:         EntryOptions{SkipExisting: true, Message: "later"},
+			want:         "2026-01-01: standup\nlater\nearlier\n\n2025-12-31:\nolder\n",
+			wantExisting: true,
+		},
+		{
+			name:         "message at the end of existing appended entry",
+			content:      "2025-12-31:\nolder\n\n2026-01-01:\nearlier\n\n",
+			order:        ENTRY_APPEND,
+			opts:         EntryOptions{SkipExisting: true, Message: "later"},
+			want:         "2025-12-31:\nolder\n\n2026-01-01:\nearlier\nlater\n",
+			wantExisting: true,
+		},
+		{
+			name:    "different stamp is not a duplicate",
+			content: "2026-01-01:\nearlier\n",
+			order:   ENTRY_PREPEND,
+			opts:    EntryOptions{SkipExisting: true, Format: ENTRY_DATETIME},
+			want:    "2026-01-01 09:05:\n\n\n2026-01-01:\nearlier\n",
+		},
+	}
+	for _, tt := range tests {
+		got, existing := addEntry(tt.content, ts, tt.order, tt.opts)
+		if got != tt.want || existing != tt.wantExisting {
+			t.Errorf("%v: entry mismatch:\nexpected: %q (existing %v)\ngot: %q (existing %v)", tt.name, tt.want, tt.wantExisting, got, existing)
+		}
+	}
+}
+
+func TestEntryOrder(t *testing.T) {
+	fields := Fields{{Key: "Entry-Order", Value: "Append"}}
+	if order, err := entryOrder(fields, ""); err != nil || order != ENTRY_APPEND {
+		t.Errorf("expected the header field to be used, got %v %v", order, err)
+	}
+	if order, err := entryOrder(fields, ENTRY_PREPEND); err != nil || order != ENTRY_PREPEND {
+		t.Errorf("expected the flag to win, got %v %v", order, err)
+	}
+	if order, err := entryOrder(nil, ""); err != nil || order != ENTRY_PREPEND {
+		t.Errorf("expected prepend by default, got %v %v", order, err)
+	}
+	if _, err := entryOrder(Fields{{Key: ENTRY_ORDER_FIELD, Value: "sideways"}}, ""); err == nil {
+		t.Errorf("expected an error for an unknown order")
+	}
+}
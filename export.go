@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+const HTML_INDEX = "index.html"
+
+// directory of the exported site that tag pages go in
+const HTML_TAGS_DIR = "tags"
+
+// links to other notes in note content, either [[path]] and [[path|label]] relative to the notebook
+// or markdown's [label](path) relative to the note
+var noteLinkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]+))?\]\]|\[([^\[\]\n]+)\]\(([^()\s]+)\)`)
+
+type siteNote struct {
+	Note Note
+	// path of the note relative to the notebook
+	Rel string
+	// path of the note's page relative to the site
+	Page      string
+	Tags      []*siteTag
+	Content   template.HTML
+	Backlinks []*siteNote
+}
+
+func (n *siteNote) Title() string {
+	if len(n.Note.Title) > 0 {
+		return n.Note.Title
+	}
+	return noteName(n.Note.Path)
+}
+
+// Fields are the header fields that don't already have a place on the page
+func (n *siteNote) Fields() Fields {
+	results := make(Fields, 0)
+	for i, field := range n.Note.Fields {
+		key := strings.ToLower(field.Key)
+		if key != "title" && key != "tags" && n.Note.Fields.lastIndex(key) == i {
+			results = append(results, field)
+		}
+	}
+	return results
+}
+
+type siteDir struct {
+	// path of the directory relative to the notebook, . for the notebook itself
+	Dir   string
+	Page  string
+	Dirs  []*siteDir
+	Notes []*siteNote
+}
+
+func (d *siteDir) Name() string {
+	if d.Dir == "." {
+		return "notes"
+	}
+	return path.Base(d.Dir)
+}
+
+type siteTag struct {
+	Tag   string
+	Page  string
+	Notes []*siteNote
+}
+
+// htmlSite works out every page of an exported notebook up front, so that pages can link to each other
+type htmlSite struct {
+	notes []*siteNote
+	dirs  map[string]*siteDir
+	tags  []*siteTag
+	// notes by their path relative to the notebook, with and without the extension
+	lookup  map[string]*siteNote
+	claimed map[string]bool
+}
+
+// claim reserves a page path, numbering it if it's already taken
+func (s *htmlSite) claim(page string) string {
+	candidate := page
+	for i := 2; s.claimed[candidate]; i++ {
+		candidate = fmt.Sprintf("%v-%v.html", strings.TrimSuffix(page, ".html"), i)
+	}
+	s.claimed[candidate] = true
+	return candidate
+}
+
+// dir finds the directory's index, adding it and its parents to the site if needed
+func (s *htmlSite) dir(dir string) *siteDir {
+	if existing, ok := s.dirs[dir]; ok {
+		return existing
+	}
+	d := &siteDir{Dir: dir, Page: s.claim(path.Join(dir, HTML_INDEX))}
+	s.dirs[dir] = d
+	if dir != "." {
+		parent := s.dir(path.Dir(dir))
+		parent.Dirs = append(parent.Dirs, d)
+	}
+	return d
+}
+
+// tagSlug makes a tag safe to use as a file name
+func tagSlug(tag string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(tag) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	if b.Len() == 0 {
+		return "tag"
+	}
+	return b.String()
+}
+
+func newHTMLSite(root string, notes []Note) *htmlSite {
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Path < notes[j].Path })
+	s := &htmlSite{
+		dirs:    make(map[string]*siteDir),
+		lookup:  make(map[string]*siteNote),
+		claimed: map[string]bool{path.Join(HTML_TAGS_DIR, HTML_INDEX): true},
+	}
+	s.dir(".")
+	for _, note := range notes {
+		n := &siteNote{Note: note, Rel: indexKey(root, note.Path)}
+		s.notes = append(s.notes, n)
+		s.dir(path.Dir(n.Rel))
+	}
+
+	// tags are grouped regardless of case, the same as they're matched
+	byTag := make(map[string]*siteTag)
+	for _, n := range s.notes {
+		for _, tag := range n.Note.Tags {
+			t, ok := byTag[strings.ToLower(tag)]
+			if !ok {
+				t = &siteTag{Tag: tag}
+				byTag[strings.ToLower(tag)] = t
+				s.tags = append(s.tags, t)
+			}
+			t.Notes = append(t.Notes, n)
+			n.Tags = append(n.Tags, t)
+		}
+	}
+	sort.Slice(s.tags, func(i, j int) bool { return strings.ToLower(s.tags[i].Tag) < strings.ToLower(s.tags[j].Tag) })
+	for _, t := range s.tags {
+		t.Page = s.claim(path.Join(HTML_TAGS_DIR, tagSlug(t.Tag)+".html"))
+	}
+
+	for _, n := range s.notes {
+		ext, _ := noteExtension(n.Rel)
+		withoutExt := strings.TrimSuffix(n.Rel, ext)
+		n.Page = s.claim(withoutExt + ".html")
+		s.lookup[n.Rel] = n
+		if _, ok := s.lookup[withoutExt]; !ok {
+			s.lookup[withoutExt] = n
+		}
+		d := s.dirs[path.Dir(n.Rel)]
+		d.Notes = append(d.Notes, n)
+	}
+	for _, n := range s.notes {
+		n.Content = s.renderContent(n)
+	}
+	return s
+}
+
+// resolve finds the note a link points to, relative links are relative to the note they're in
+func (s *htmlSite) resolve(from *siteNote, target string, relative bool) *siteNote {
+	if strings.Contains(target, "://") {
+		return nil
+	}
+	target, _, _ = strings.Cut(target, "#")
+	if relative && !strings.HasPrefix(target, "/") {
+		target = path.Join(path.Dir(from.Rel), target)
+	}
+	return s.lookup[path.Clean(strings.TrimPrefix(target, "/"))]
+}
+
+// renderContent escapes a note's content, turning links to other notes into links to their pages
+func (s *htmlSite) renderContent(n *siteNote) template.HTML {
+	content := n.Note.Content
+	var b strings.Builder
+	last := 0
+	for _, match := range noteLinkPattern.FindAllStringSubmatchIndex(content, -1) {
+		b.WriteString(template.HTMLEscapeString(content[last:match[0]]))
+		last = match[1]
+
+		var target, label string
+		relative := false
+		if match[2] >= 0 {
+			target = content[match[2]:match[3]]
+			label = target
+			if match[4] >= 0 {
+				label = content[match[4]:match[5]]
+			}
+		} else {
+			label = content[match[6]:match[7]]
+			target = content[match[8]:match[9]]
+			relative = true
+		}
+		linked := s.resolve(n, strings.TrimSpace(target), relative)
+		if linked == nil {
+			b.WriteString(template.HTMLEscapeString(content[match[0]:match[1]]))
+			continue
+		}
+		fmt.Fprintf(&b, `<a href="%v">%v</a>`, template.HTMLEscapeString(relLink(n.Page, linked.Page)), template.HTMLEscapeString(strings.TrimSpace(label)))
+		if linked != n && !hasSiteNote(linked.Backlinks, n) {
+			linked.Backlinks = append(linked.Backlinks, n)
+		}
+	}
+	b.WriteString(template.HTMLEscapeString(content[last:]))
+	return template.HTML(b.String())
+}
+
+func hasSiteNote(notes []*siteNote, n *siteNote) bool {
+	for _, existing := range notes {
+		if existing == n {
+			return true
+		}
+	}
+	return false
+}
+
+// relLink is the link from one page of the site to another, relative so the site works from anywhere
+func relLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// sitePage is what every page template gets, only the fields for that kind of page are set
+type sitePage struct {
+	Title string
+	Page  string
+	Note  *siteNote
+	Dir   *siteDir
+	Tag   *siteTag
+	Tags  []*siteTag
+}
+
+var siteTemplates = template.Must(template.New("site").Funcs(template.FuncMap{"link": relLink}).Parse(`
+{{define "top"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; line-height: 1.5; }
+nav a, .tags a { margin-right: 0.5em; }
+.path { color: #666; }
+pre { white-space: pre-wrap; font-family: monospace; }
+dt { font-weight: bold; }
+</style>
+</head>
+<body>
+<nav><a href="{{link .Page "` + HTML_INDEX + `"}}">notes</a><a href="{{link .Page "` + HTML_TAGS_DIR + "/" + HTML_INDEX + `"}}">tags</a></nav>
+<h1>{{.Title}}</h1>
+{{end}}
+
+{{define "bottom"}}</body>
+</html>
+{{end}}
+
+{{define "note"}}{{template "top" .}}{{$page := .Page}}{{with .Note}}
+<p class="path">{{.Rel}}</p>
+{{with .Tags}}<p class="tags">{{range .}}<a href="{{link $page .Page}}">{{.Tag}}</a>{{end}}</p>{{end}}
+{{with .Fields}}<dl>{{range .}}<dt>{{.Key}}</dt><dd>{{.Value}}</dd>{{end}}</dl>{{end}}
+<pre>{{.Content}}</pre>
+{{with .Backlinks}}<h2>Linked from</h2>
+<ul>{{range .}}<li><a href="{{link $page .Page}}">{{.Title}}</a></li>{{end}}</ul>{{end}}
+{{end}}{{template "bottom" .}}{{end}}
+
+{{define "dir"}}{{template "top" .}}{{$page := .Page}}{{with .Dir}}
+{{with .Dirs}}<h2>Directories</h2>
+<ul>{{range .}}<li><a href="{{link $page .Page}}">{{.Name}}/</a></li>{{end}}</ul>{{end}}
+{{with .Notes}}<h2>Notes</h2>
+<ul>{{range .}}<li><a href="{{link $page .Page}}">{{.Title}}</a>{{range .Tags}} <a class="tags" href="{{link $page .Page}}">#{{.Tag}}</a>{{end}}</li>{{end}}</ul>{{end}}
+{{end}}{{template "bottom" .}}{{end}}
+
+{{define "tag"}}{{template "top" .}}{{$page := .Page}}
+<ul>{{range .Tag.Notes}}<li><a href="{{link $page .Page}}">{{.Title}}</a> <span class="path">{{.Rel}}</span></li>{{end}}</ul>
+{{template "bottom" .}}{{end}}
+
+{{define "tags"}}{{template "top" .}}{{$page := .Page}}
+<ul>{{range .Tags}}<li><a href="{{link $page .Page}}">{{.Tag}}</a> ({{len .Notes}})</li>{{end}}</ul>
+{{template "bottom" .}}{{end}}
+`))
+
+// pages lists every page of the site along with the template it's rendered with
+func (s *htmlSite) pages() map[string]sitePage {
+	results := map[string]sitePage{
+		path.Join(HTML_TAGS_DIR, HTML_INDEX): {Title: "tags", Page: path.Join(HTML_TAGS_DIR, HTML_INDEX), Tags: s.tags},
+	}
+	for _, d := range s.dirs {
+		sort.Slice(d.Dirs, func(i, j int) bool { return d.Dirs[i].Dir < d.Dirs[j].Dir })
+		results[d.Page] = sitePage{Title: d.Name(), Page: d.Page, Dir: d}
+	}
+	for _, t := range s.tags {
+		results[t.Page] = sitePage{Title: t.Tag, Page: t.Page, Tag: t}
+	}
+	for _, n := range s.notes {
+		results[n.Page] = sitePage{Title: n.Title(), Page: n.Page, Note: n}
+	}
+	return results
+}
+
+func (p sitePage) template() string {
+	switch {
+	case p.Note != nil:
+		return "note"
+	case p.Dir != nil:
+		return "dir"
+	case p.Tag != nil:
+		return "tag"
+	}
+	return "tags"
+}
+
+// ExportHTML writes a page for every note to out, along with an index of every directory and tag.
+// returns how many pages were written
+func ExportHTML(root string, notes []Note, out string) (int, error) {
+	site := newHTMLSite(root, notes)
+	pages := site.pages()
+	for page, data := range pages {
+		var b bytes.Buffer
+		if err := siteTemplates.ExecuteTemplate(&b, data.template(), data); err != nil {
+			return 0, fmt.Errorf("could not render %v: %w", page, err)
+		}
+		dest := filepath.Join(out, filepath.FromSlash(page))
+		if err := os.MkdirAll(filepath.Dir(dest), 0770); err != nil {
+			return 0, fmt.Errorf("could not create directory: %w", err)
+		}
+		if err := os.WriteFile(dest, b.Bytes(), 0666); err != nil {
+			return 0, fmt.Errorf("could not write page: %v, %w", dest, err)
+		}
+	}
+	return len(pages), nil
+}
+
+var exportOut string
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "exports the notebook to other formats",
+}
+
+var exportHTMLCmd = &cobra.Command{
+	Use:   "html",
+	Short: "exports the notebook as a static html site",
+	Long: `exports the notebook as a static html site with a page for every note, and index pages for every
+directory and tag. [[path]] and markdown [label](path) links to other notes become links between their pages.
+links are relative so the site can be opened straight from the disk or served from anywhere`,
+	Example: "notes export html --out site",
+	Args:    cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		root, err := notesRoot()
+		if err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		notes, err := collectFiles(false)
+		if err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		pages, err := ExportHTML(root, notes, exportOut)
+		if err != nil {
+			fmt.Printf("Problem trying to export: %v", err)
+			return
+		}
+		fmt.Printf("Exported %v notes as %v pages to %v\n", len(notes), pages, exportOut)
+	},
+}
+
+func init() {
+	exportHTMLCmd.Flags().StringVar(&exportOut, "out", "", "directory to write the site to")
+	_ = exportHTMLCmd.MarkFlagRequired("out")
+	exportCmd.AddCommand(exportHTMLCmd)
+}
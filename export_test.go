@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportHTML(t *testing.T) {
+	root := t.TempDir()
+	out := t.TempDir()
+	notes := []Note{
+		{
+			Path:    filepath.Join(root, "work", "plan.txt"),
+			Title:   "Plan <b>",
+			Tags:    []string{"Work", "q3 goals"},
+			Fields:  Fields{{Key: "title", Value: "Plan <b>"}, {Key: "owner", Value: "me"}},
+			Content: "see [[idea]], [[work/other|the other]] and [[missing]] <script>\n",
+		},
+		{
+			Path:    filepath.Join(root, "work", "other.md"),
+			Title:   "Other",
+			Tags:    []string{"work"},
+			Content: "back to [the plan](plan.txt)\n",
+		},
+		{Path: filepath.Join(root, "idea.txt"), Content: "untitled\n"},
+		{Path: filepath.Join(root, "index.txt"), Title: "Not the index"},
+	}
+
+	pages, err := ExportHTML(root, notes, out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 4 notes, 2 directories, 2 tags and the tag index
+	if pages != 9 {
+		t.Errorf("expected 9 pages, got %v", pages)
+	}
+	read := func(page string) string {
+		data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(page)))
+		if err != nil {
+			t.Fatalf("expected page %v: %v", page, err)
+		}
+		return string(data)
+	}
+
+	plan := read("work/plan.html")
+	for _, want := range []string{
+		"<h1>Plan &lt;b&gt;</h1>",
+		`<a href="../tags/work.html">work</a>`,
+		`<a href="../tags/q3-goals.html">q3 goals</a>`,
+		"<dt>owner</dt><dd>me</dd>",
+		`see <a href="../idea.html">idea</a>, <a href="other.html">the other</a> and [[missing]] &lt;script&gt;`,
+		`<a href="other.html">Other</a>`,
+	} {
+		if !strings.Contains(plan, want) {
+			t.Errorf("expected plan page to contain %q:\n%v", want, plan)
+		}
+	}
+	if !strings.Contains(read("work/other.html"), `back to <a href="plan.html">the plan</a>`) {
+		t.Errorf("expected a relative markdown link to the plan")
+	}
+	if !strings.Contains(read("idea.html"), "<h1>idea</h1>") {
+		t.Errorf("expected an untitled note to be titled by its name")
+	}
+	// the directory index takes index.html, so the note named index gets the next free page
+	index := read("index.html")
+	if !strings.Contains(index, `<a href="work/index.html">work/</a>`) || !strings.Contains(index, `<a href="index-2.html">Not the index</a>`) {
+		t.Errorf("unexpected root index:\n%v", index)
+	}
+	if tag := read("tags/work.html"); !strings.Contains(tag, `<a href="../work/other.html">Other</a>`) || !strings.Contains(tag, `<a href="../work/plan.html">Plan &lt;b&gt;</a>`) {
+		t.Errorf("expected tags to be grouped regardless of case:\n%v", tag)
+	}
+	if tags := read("tags/index.html"); !strings.Contains(tags, `<a href="q3-goals.html">q3 goals</a> (1)`) {
+		t.Errorf("unexpected tag index:\n%v", tags)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type Field struct {
+	// the key as it was written in the header
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Fields are every key: value line of a note's header, in the order they were written
+type Fields []Field
+
+// Get looks up a field regardless of case, if a field is repeated the last one wins like it does for the parser
+func (f Fields) Get(key string) (string, bool) {
+	for i := len(f) - 1; i >= 0; i-- {
+		if strings.EqualFold(f[i].Key, key) {
+			return f[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// MarshalJSON writes the fields as an object keyed by the lower cased key, keeping the header's order
+func (f Fields) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	written := 0
+	for i, field := range f {
+		key := strings.ToLower(field.Key)
+		// only the last of a repeated field counts
+		if f.lastIndex(key) != i {
+			continue
+		}
+		if written > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+		written++
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// UnmarshalJSON reads fields written by MarshalJSON, keeping the order they were written in
+func (f *Fields) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("fields have to be an object")
+	}
+	results := make(Fields, 0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		var value string
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("field `%v` has to be text: %w", token, err)
+		}
+		results = append(results, Field{Key: token.(string), Value: value})
+	}
+	*f = results
+	return nil
+}
+
+func (f Fields) lastIndex(key string) int {
+	for i := len(f) - 1; i >= 0; i-- {
+		if strings.EqualFold(f[i].Key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetNoteField changes a header field of the note at filePath, adding it if it's missing
+func SetNoteField(filePath, key, value string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("could not read file: %v, %w", filePath, err)
+	}
+	out, err := formatFor(filePath).SetField(data, key, value)
+	if err != nil {
+		return err
+	}
+	if _, err := parseNote(bytes.NewReader(out), filePath, true); err != nil {
+		return fmt.Errorf("refusing to write an invalid header: %w", err)
+	}
+	return writeNoteFile(filePath, out)
+}
+
+// UnsetNoteField removes a header field from the note at filePath
+func UnsetNoteField(filePath, key string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("could not read file: %v, %w", filePath, err)
+	}
+	out, removed := formatFor(filePath).RemoveField(data, key)
+	if !removed {
+		return fmt.Errorf("note does not have a `%v` field", key)
+	}
+	if _, err := parseNote(bytes.NewReader(out), filePath, true); err != nil {
+		return fmt.Errorf("refusing to write an invalid header: %w", err)
+	}
+	return writeNoteFile(filePath, out)
+}
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "reads and changes the header fields of a note",
+}
+
+var metaGetCmd = &cobra.Command{
+	Use:     "get",
+	Short:   "outputs the value of a header field, or every field if no key is given",
+	Example: "notes meta get <note> [key]",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.RangeArgs(1, 2)(cmd, args); err != nil {
+			return err
+		}
+		return optionalExistingNote(cmd, args)
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		note, err := readNote(args[0], true)
+		if err != nil {
+			fmt.Printf("Problem trying to get field: %v", err)
+			return
+		}
+		if len(args) == 1 {
+			err = writeOutput(note.Fields, func(field Field) {
+				fmt.Printf("%v: %v\n", field.Key, field.Value)
+			})
+			if err != nil {
+				fmt.Printf("Problem trying to output fields: %v", err)
+			}
+			return
+		}
+		value, ok := note.Fields.Get(args[1])
+		if !ok {
+			fmt.Printf("Note does not have a `%v` field", args[1])
+			return
+		}
+		fmt.Println(value)
+	},
+}
+
+var metaSetCmd = &cobra.Command{
+	Use:     "set",
+	Short:   "changes a header field, adding it if the note doesn't have it",
+	Example: "notes meta set <note> status open",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(3)(cmd, args); err != nil {
+			return err
+		}
+		return optionalExistingNote(cmd, args)
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		if err := SetNoteField(args[0], args[1], strings.Join(args[2:], " ")); err != nil {
+			fmt.Printf("Problem trying to set field: %v", err)
+		}
+	},
+}
+
+var metaUnsetCmd = &cobra.Command{
+	Use:     "unset",
+	Short:   "removes a header field",
+	Example: "notes meta unset <note> status",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		return optionalExistingNote(cmd, args)
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		if err := UnsetNoteField(args[0], args[1]); err != nil {
+			fmt.Printf("Problem trying to unset field: %v", err)
+		}
+	},
+}
+
+func init() {
+	metaCmd.AddCommand(metaGetCmd)
+	metaCmd.AddCommand(metaSetCmd)
+	metaCmd.AddCommand(metaUnsetCmd)
+}
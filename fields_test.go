@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseNoteFields(t *testing.T) {
+	got, err := parseNoteConbini("mic_drop.txt", true)
+	if err != nil {
+		t.Fatalf("unexpected error occured while parsing note: %v", err)
+	}
+	if len(got.Fields) != 14 {
+		t.Fatalf("expected 14 fields, got %v", len(got.Fields))
+	}
+	want := Field{Key: "<howling>", Value: "@@@%%%%%@@@@@@@@@@@@@@@@@@@@@@"}
+	if got.Fields[13] != want {
+		t.Errorf("field mismatch:\nexpected: %+v\ngot: %+v", want, got.Fields[13])
+	}
+	if value, ok := got.Fields.Get("TITLE"); !ok || value != "mic_drop" {
+		t.Errorf("expected case insensitive lookup of title, got %q", value)
+	}
+}
+
+func TestFieldsMarshalJSON(t *testing.T) {
+	fields := Fields{
+		{Key: "Title", Value: "a"},
+		{Key: "status", Value: "open"},
+		{Key: "due", Value: "2026-11-01"},
+		{Key: "Status", Value: "closed"},
+	}
+	got, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"title":"a","due":"2026-11-01","status":"closed"}`
+	if string(got) != want {
+		t.Errorf("json mismatch:\nexpected: %v\ngot: %v", want, string(got))
+	}
+}
+
+func TestHeaderFieldEdits(t *testing.T) {
+	note := []byte("title: a\r\nStatus:open\r\n------\r\nstatus: body\r\n")
+
+	set, err := setHeaderField(note, "status", "closed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "title: a\r\nStatus:closed\r\n------\r\nstatus: body\r\n"; string(set) != want {
+		t.Errorf("set existing mismatch:\nexpected: %q\ngot: %q", want, string(set))
+	}
+
+	added, err := setHeaderField(note, "due", "2026-11-01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "title: a\r\nStatus:open\r\ndue: 2026-11-01\r\n------\r\nstatus: body\r\n"; string(added) != want {
+		t.Errorf("set new mismatch:\nexpected: %q\ngot: %q", want, string(added))
+	}
+
+	removed, ok := removeHeaderField(note, "STATUS")
+	if !ok {
+		t.Fatalf("expected field to be removed")
+	}
+	if want := "title: a\r\n------\r\nstatus: body\r\n"; string(removed) != want {
+		t.Errorf("unset mismatch:\nexpected: %q\ngot: %q", want, string(removed))
+	}
+
+	for _, bad := range [][2]string{{"", "x"}, {"a:b", "x"}, {"key", "multi\nline"}} {
+		if _, err := setHeaderField(note, bad[0], bad[1]); err == nil {
+			t.Errorf("expected error setting %q to %q", bad[0], bad[1])
+		}
+	}
+	if _, err := setHeaderField([]byte("title: no divider\n"), "status", "open"); err == nil {
+		t.Errorf("expected error setting a field on a note without a divider")
+	}
+
+	parsed, err := ParseNote(bytes.NewReader(added), "note.txt", true)
+	if err != nil {
+		t.Fatalf("could not parse edited note: %v", err)
+	}
+	wantFields := Fields{{Key: "title", Value: "a"}, {Key: "Status", Value: "open"}, {Key: "due", Value: "2026-11-01"}}
+	if !reflect.DeepEqual(wantFields, parsed.Fields) {
+		t.Errorf("fields mismatch:\nexpected: %+v\ngot: %+v", wantFields, parsed.Fields)
+	}
+}
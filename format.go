@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// NoteFormat reads and changes one kind of note file. every format produces the same Note, so commands don't
+// need to care which format a note is written in
+type NoteFormat interface {
+	// Name is how the format is referred to, e.g. by convert
+	Name() string
+	// Extensions are the file extensions of notes in this format, the first one is used for new notes
+	Extensions() []string
+	Parse(reader io.Reader, path string, justHeader bool) (*Note, error)
+	// Split separates a note's raw contents into its header, including whatever ends it, and its content
+	Split(data []byte) (header, content []byte, ok bool)
+	// Render writes a whole note from its header fields and content
+	Render(fields Fields, content string) ([]byte, error)
+	// SetField changes the value of a header field, adding it if the note doesn't have it yet
+	SetField(data []byte, field, value string) ([]byte, error)
+	// RemoveField drops a header field, the returned bool is whether the note had it
+	RemoveField(data []byte, field string) ([]byte, bool)
+	// RewriteField updates the value of a header field wherever it's set, the returned bool is whether anything changed
+	RewriteField(data []byte, field string, update func(value string) string) ([]byte, bool)
+}
+
+// checked in order when working out the format of a file, so that markdown wins even if the extension setting is .md
+var noteFormats = []NoteFormat{markdownFormat{}, txtFormat{}}
+
+// findFormat looks up a format by its name
+func findFormat(name string) (NoteFormat, error) {
+	names := make([]string, len(noteFormats))
+	for i, format := range noteFormats {
+		if strings.EqualFold(format.Name(), name) {
+			return format, nil
+		}
+		names[i] = format.Name()
+	}
+	return nil, fmt.Errorf("unknown note format `%v`, expected one of %v", name, strings.Join(names, ", "))
+}
+
+// formatFor works out the format of a note from its path, anything unknown is treated as txt
+func formatFor(path string) NoteFormat {
+	for _, format := range noteFormats {
+		for _, ext := range format.Extensions() {
+			if strings.HasSuffix(strings.ToLower(path), strings.ToLower(ext)) {
+				return format
+			}
+		}
+	}
+	return txtFormat{}
+}
+
+// noteExtensions is every extension notes are looked for with
+func noteExtensions() []string {
+	results := make([]string, 0)
+	for _, format := range noteFormats {
+		results = append(results, format.Extensions()...)
+	}
+	return results
+}
+
+// noteExtension returns the extension path ends with, if it's one notes can have
+func noteExtension(path string) (string, bool) {
+	for _, ext := range noteExtensions() {
+		if strings.HasSuffix(strings.ToLower(path), strings.ToLower(ext)) {
+			return path[len(path)-len(ext):], true
+		}
+	}
+	return "", false
+}
+
+// noteName is the file name of a note without its extension, which is what notes are titled by default
+func noteName(path string) string {
+	name := filepath.Base(path)
+	if ext, ok := noteExtension(name); ok {
+		return strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// parseNote reads a note in whatever format its path says it's in
+func parseNote(reader io.Reader, path string, justHeader bool) (*Note, error) {
+	return formatFor(path).Parse(reader, path, justHeader)
+}
+
+// txtFormat is the original format, key: value header lines ended by the divider
+type txtFormat struct{}
+
+func (txtFormat) Name() string {
+	return "txt"
+}
+
+// Extensions includes the extension setting, unless that's set to markdown's
+func (txtFormat) Extensions() []string {
+	ext := strings.ToLower(settings.Extension)
+	for _, markdownExt := range (markdownFormat{}).Extensions() {
+		if ext == markdownExt {
+			return []string{".txt"}
+		}
+	}
+	if ext == ".txt" {
+		return []string{".txt"}
+	}
+	return []string{settings.Extension, ".txt"}
+}
+
+func (txtFormat) Parse(reader io.Reader, path string, justHeader bool) (*Note, error) {
+	return ParseNote(reader, path, justHeader)
+}
+
+func (txtFormat) Split(data []byte) ([]byte, []byte, bool) {
+	lines, rest, ok := splitHeader(data)
+	if !ok {
+		return nil, nil, false
+	}
+	divider := rest
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		divider = rest[:i+1]
+	}
+	return joinHeader(lines, divider), rest[len(divider):], true
+}
+
+func (txtFormat) Render(fields Fields, content string) ([]byte, error) {
+	var b strings.Builder
+	for _, field := range fields {
+		if len(field.Value) == 0 {
+			fmt.Fprintf(&b, "%v:\n", field.Key)
+			continue
+		}
+		fmt.Fprintf(&b, "%v: %v\n", field.Key, field.Value)
+	}
+	b.WriteString(settings.Divider + "\n")
+	b.WriteString(content)
+	return []byte(b.String()), nil
+}
+
+func (txtFormat) SetField(data []byte, field, value string) ([]byte, error) {
+	return setHeaderField(data, field, value)
+}
+
+func (txtFormat) RemoveField(data []byte, field string) ([]byte, bool) {
+	return removeHeaderField(data, field)
+}
+
+func (txtFormat) RewriteField(data []byte, field string, update func(value string) string) ([]byte, bool) {
+	return rewriteHeaderField(data, field, update)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// splitHeader splits a note's raw contents into its header lines, each with its line ending,
+// and everything from the divider onwards. ok is false if there is no divider
+func splitHeader(data []byte) (lines [][]byte, rest []byte, ok bool) {
+	lines = make([][]byte, 0)
+	rest = data
+	for len(rest) > 0 {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		if strings.TrimSpace(string(line)) == settings.Divider {
+			return lines, rest, true
+		}
+		lines = append(lines, line)
+		rest = rest[len(line):]
+	}
+	return lines, nil, false
+}
+
+func joinHeader(lines [][]byte, rest []byte) []byte {
+	return append(bytes.Join(lines, nil), rest...)
+}
+
+// headerLineField returns the lower cased field name of a header line, the same way ParseNote reads it
+func headerLineField(line []byte) (string, bool) {
+	name, _, ok := strings.Cut(string(line), ":")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(strings.ToLower(name)), true
+}
+
+// rewriteHeaderField replaces the value of every line for field in the header of a note's raw contents.
+// everything other than the value, including the spacing after the colon and line endings, is kept as is.
+// update gets the current value without surrounding space, the returned bool is whether anything changed
+func rewriteHeaderField(data []byte, field string, update func(value string) string) ([]byte, bool) {
+	lines, rest, _ := splitHeader(data)
+	field = strings.ToLower(field)
+	changed := false
+	for i, line := range lines {
+		if name, ok := headerLineField(line); !ok || name != field {
+			continue
+		}
+		text := strings.TrimRight(string(line), "\r\n")
+		name, value, _ := strings.Cut(text, ":")
+		trimmed := strings.TrimSpace(value)
+		newValue := update(trimmed)
+		if newValue == trimmed {
+			continue
+		}
+		changed = true
+		leading := value[:len(value)-len(strings.TrimLeft(value, " \t"))]
+		if len(leading) == 0 && len(trimmed) == 0 {
+			leading = " "
+		}
+		var b strings.Builder
+		b.WriteString(name + ":")
+		if len(newValue) > 0 {
+			b.WriteString(leading + newValue)
+		}
+		b.WriteString(string(line[len(text):]))
+		lines[i] = []byte(b.String())
+	}
+	return joinHeader(lines, rest), changed
+}
+
+// setHeaderField changes the value of field, adding it to the end of the header if the note doesn't have it yet
+func setHeaderField(data []byte, field, value string) ([]byte, error) {
+	if err := validateHeaderField(field, value); err != nil {
+		return nil, err
+	}
+	lines, rest, ok := splitHeader(data)
+	if !ok {
+		return nil, fmt.Errorf("note has no %v divider", settings.Divider)
+	}
+	for _, line := range lines {
+		if name, ok := headerLineField(line); ok && name == strings.ToLower(field) {
+			out, _ := rewriteHeaderField(data, field, func(string) string { return value })
+			return out, nil
+		}
+	}
+
+	// match the line ending the divider uses
+	ending := "\n"
+	if i := bytes.IndexByte(rest, '\n'); i > 0 && rest[i-1] == '\r' {
+		ending = "\r\n"
+	}
+	lines = append(lines, []byte(fmt.Sprintf("%v: %v%v", field, value, ending)))
+	return joinHeader(lines, rest), nil
+}
+
+// removeHeaderField drops every line for field from the header
+func removeHeaderField(data []byte, field string) ([]byte, bool) {
+	lines, rest, _ := splitHeader(data)
+	kept := make([][]byte, 0, len(lines))
+	for _, line := range lines {
+		if name, ok := headerLineField(line); ok && name == strings.ToLower(field) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return joinHeader(kept, rest), len(kept) != len(lines)
+}
+
+func validateHeaderField(field, value string) error {
+	if len(strings.TrimSpace(field)) == 0 {
+		return fmt.Errorf("empty header field name")
+	}
+	if strings.ContainsAny(field, ":\r\n") || strings.TrimSpace(field) != field {
+		return fmt.Errorf("invalid header field name `%v`", field)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("header values can not span multiple lines")
+	}
+	if strings.TrimSpace(field) == settings.Divider {
+		return fmt.Errorf("header field name can not be the divider")
+	}
+	return nil
+}
+
+// lineDiff shows the lines that differ between two versions of a file, good enough for header rewrites
+// where lines are only ever changed in place
+func lineDiff(path string, before, after []byte) string {
+	oldLines := strings.Split(string(before), "\n")
+	newLines := strings.Split(string(after), "\n")
+	var b strings.Builder
+	b.WriteString("--- " + path + "\n+++ " + path + "\n")
+	for i := 0; i < len(oldLines) || i < len(newLines); i++ {
+		var oldLine, newLine string
+		if i < len(oldLines) {
+			oldLine = oldLines[i]
+		}
+		if i < len(newLines) {
+			newLine = newLines[i]
+		}
+		if oldLine == newLine {
+			continue
+		}
+		fmt.Fprintf(&b, "@@ line %v @@\n", i+1)
+		if i < len(oldLines) {
+			b.WriteString("-" + oldLine + "\n")
+		}
+		if i < len(newLines) {
+			b.WriteString("+" + newLine + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// METADATA_DIR holds everything notes keeps about a notebook, it is skipped when looking for notes
+const METADATA_DIR = ".notes"
+const INDEX_FILE = "index"
+
+// bump this whenever indexEntry changes so that old indexes get thrown away
+const INDEX_VERSION = 4
+
+type indexEntry struct {
+	ModTime time.Time `json:"mtime"`
+	Size    int64     `json:"size"`
+	// the file couldn't be read as a note, it's kept so it doesn't get read again until it changes
+	Invalid bool   `json:"invalid,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	// the file was read as an untitled note because its header isn't valid
+	HeaderErr string   `json:"header_error,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Fields    []Field  `json:"fields,omitempty"`
+	RawHeader string   `json:"header,omitempty"`
+}
+
+// noteIndex caches the parsed headers of every note in a notebook, keyed by the note's path relative to the notebook
+type noteIndex struct {
+	Version int `json:"version"`
+	// the settings headers were read with, the index is thrown away when they change
+	Divider   string                `json:"divider"`
+	Extension string                `json:"extension"`
+	Entries   map[string]indexEntry `json:"entries"`
+	changed   bool
+}
+
+func indexPath(dir string) string {
+	return filepath.Join(dir, METADATA_DIR, INDEX_FILE)
+}
+
+// loadIndex reads the index for the notebook at dir. a missing or unusable index just results in an empty one
+func loadIndex(dir string) *noteIndex {
+	idx := &noteIndex{
+		Version:   INDEX_VERSION,
+		Divider:   settings.Divider,
+		Extension: settings.Extension,
+		Entries:   make(map[string]indexEntry),
+	}
+	data, err := os.ReadFile(indexPath(dir))
+	if err != nil {
+		return idx
+	}
+	loaded := &noteIndex{}
+	if err := json.Unmarshal(data, loaded); err != nil || loaded.Version != INDEX_VERSION || loaded.Entries == nil ||
+		loaded.Divider != settings.Divider || loaded.Extension != settings.Extension {
+		idx.changed = true
+		return idx
+	}
+	return loaded
+}
+
+func (idx *noteIndex) save(dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, METADATA_DIR), 0770); err != nil {
+		return fmt.Errorf("could not create %v directory: %w", METADATA_DIR, err)
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("could not encode index: %w", err)
+	}
+	if err := writeFileAtomic(indexPath(dir), data, 0666); err != nil {
+		return fmt.Errorf("could not write index: %w", err)
+	}
+	idx.changed = false
+	return nil
+}
+
+func indexKey(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// refresh brings the index up to date with fileList and returns the header of every note in it, along with
+// the files that couldn't be read as notes. only files whose modification time or size changed since they were
+// indexed get parsed
+func (idx *noteIndex) refresh(dir string, fileList []string) ([]Note, []SkippedFile) {
+	notes := make([]Note, 0, len(fileList))
+	skipped := make([]SkippedFile, 0)
+	stale := make([]string, 0)
+	seen := make(map[string]bool, len(fileList))
+	for _, path := range fileList {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		key := indexKey(dir, path)
+		seen[key] = true
+		entry, ok := idx.Entries[key]
+		if ok && entry.ModTime.Equal(info.ModTime()) && entry.Size == info.Size() {
+			if entry.Invalid {
+				skipped = append(skipped, SkippedFile{Path: path, Reason: entry.Reason})
+				continue
+			}
+			note := Note{
+				Path:      path,
+				Title:     entry.Title,
+				Tags:      entry.Tags,
+				Fields:    entry.Fields,
+				rawHeader: entry.RawHeader,
+			}
+			if len(entry.HeaderErr) > 0 {
+				note.headerErr = errors.New(entry.HeaderErr)
+			}
+			notes = append(notes, note)
+			continue
+		}
+		// assume it's invalid until it's been parsed
+		idx.Entries[key] = indexEntry{ModTime: info.ModTime(), Size: info.Size(), Invalid: true}
+		stale = append(stale, path)
+	}
+	for key := range idx.Entries {
+		if !seen[key] {
+			delete(idx.Entries, key)
+			idx.changed = true
+		}
+	}
+	if len(stale) == 0 {
+		return notes, skipped
+	}
+
+	idx.changed = true
+	parsed, parseSkipped := parseFiles(stale, true)
+	for _, note := range parsed {
+		key := indexKey(dir, note.Path)
+		entry := idx.Entries[key]
+		entry.Invalid = false
+		entry.Title = note.Title
+		entry.Tags = note.Tags
+		entry.Fields = note.Fields
+		entry.RawHeader = note.rawHeader
+		if note.headerErr != nil {
+			entry.HeaderErr = note.headerErr.Error()
+		}
+		idx.Entries[key] = entry
+		notes = append(notes, note)
+	}
+	for _, file := range parseSkipped {
+		key := indexKey(dir, file.Path)
+		entry := idx.Entries[key]
+		entry.Reason = file.Reason
+		idx.Entries[key] = entry
+	}
+	return notes, append(skipped, parseSkipped...)
+}
+
+func RebuildIndex() (int, error) {
+	root, err := notesRoot()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(indexPath(root)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("could not remove old index: %w", err)
+	}
+	idx := loadIndex(root)
+	notes, skipped := idx.refresh(root, files(root))
+	if err := idx.save(root); err != nil {
+		return 0, err
+	}
+	notes, strictSkipped := strictNotes(notes)
+	skippedFiles = append(skippedFiles, append(skipped, strictSkipped...)...)
+	return len(notes), nil
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "manages the cache of note headers",
+	Long:  "manages the cache of note headers kept in " + METADATA_DIR + "/" + INDEX_FILE + ". the index is refreshed automatically whenever notes change, so this is rarely needed.",
+}
+
+var indexRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "throws away the index and reparses every note",
+	Args:  cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		count, err := RebuildIndex()
+		if err != nil {
+			fmt.Printf("Problem trying to rebuild index: %v", err)
+			return
+		}
+		fmt.Printf("Indexed %v notes\n", count)
+	},
+}
+
+func init() {
+	indexCmd.AddCommand(indexRebuildCmd)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNoteIndexRefresh(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0660); err != nil {
+			t.Fatalf("could not write test note: %v", err)
+		}
+		return path
+	}
+	titles := func(notes []Note) []string {
+		result := make([]string, 0, len(notes))
+		for _, n := range notes {
+			result = append(result, n.Title)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	first := write("first.txt", "title: first\ntags: a, b\n------\n")
+	write("second.txt", "title: second\ntags:\n------\nbody\n")
+	write("broken.txt", "oops\n------\n")
+	binary := write("binary.txt", "\x89PNG\x00\x01")
+
+	idx := loadIndex(dir)
+	notes, skipped := idx.refresh(dir, files(dir))
+	if got := titles(notes); !reflect.DeepEqual(got, []string{"broken", "first", "second"}) {
+		t.Fatalf("unexpected notes on first refresh: %v", got)
+	}
+	if len(idx.Entries["broken.txt"].HeaderErr) == 0 {
+		t.Errorf("expected broken note to be cached as having an invalid header")
+	}
+	if !idx.Entries["binary.txt"].Invalid || len(skipped) != 1 || skipped[0].Path != binary {
+		t.Errorf("expected binary file to be cached as invalid and skipped, got %v", skipped)
+	}
+	if err := idx.save(dir); err != nil {
+		t.Fatalf("could not save index: %v", err)
+	}
+
+	idx = loadIndex(dir)
+	notes, skipped = idx.refresh(dir, files(dir))
+	if idx.changed {
+		t.Errorf("expected index to be unchanged when no notes changed")
+	}
+	if got := titles(notes); !reflect.DeepEqual(got, []string{"broken", "first", "second"}) {
+		t.Fatalf("unexpected notes from cached index: %v", got)
+	}
+	if len(skipped) != 1 || skipped[0].Reason != "not a text file" {
+		t.Errorf("expected the cached binary file to be skipped with its reason, got %v", skipped)
+	}
+	strictParse = true
+	strict, strictSkipped := strictNotes(notes)
+	strictParse = false
+	if got := titles(strict); !reflect.DeepEqual(got, []string{"first", "second"}) || len(strictSkipped) != 1 {
+		t.Errorf("expected strict parsing to skip the cached broken note, got %v %v", got, strictSkipped)
+	}
+	for _, n := range notes {
+		if n.Path == first && !reflect.DeepEqual(n.Tags, []string{"a", "b"}) {
+			t.Errorf("cached tags mismatch: %v", n.Tags)
+		}
+	}
+
+	write("first.txt", "title: renamed first\ntags: a, b\n------\n")
+	if err := os.Remove(filepath.Join(dir, "second.txt")); err != nil {
+		t.Fatalf("could not remove test note: %v", err)
+	}
+	notes, _ = idx.refresh(dir, files(dir))
+	if !idx.changed {
+		t.Errorf("expected index to change after notes changed")
+	}
+	if got := titles(notes); !reflect.DeepEqual(got, []string{"broken", "renamed first"}) {
+		t.Fatalf("unexpected notes after changes: %v", got)
+	}
+	if _, ok := idx.Entries["second.txt"]; ok {
+		t.Errorf("expected removed note to be dropped from the index")
+	}
+}
+
+func TestNoteIndexSettings(t *testing.T) {
+	dir := t.TempDir()
+	defer func() { settings = defaultConfig() }()
+	if err := os.WriteFile(filepath.Join(dir, "note.txt"), []byte("title: note\n------\n"), 0660); err != nil {
+		t.Fatalf("could not write test note: %v", err)
+	}
+	idx := loadIndex(dir)
+	idx.refresh(dir, files(dir))
+	if err := idx.save(dir); err != nil {
+		t.Fatalf("could not save index: %v", err)
+	}
+	if loaded := loadIndex(dir); len(loaded.Entries) != 1 {
+		t.Fatalf("expected the saved index to be used, got %+v", loaded)
+	}
+
+	for _, change := range []func(){
+		func() { settings.Divider = "~~~" },
+		func() { settings.Extension = ".note" },
+	} {
+		settings = defaultConfig()
+		change()
+		if loaded := loadIndex(dir); len(loaded.Entries) != 0 || !loaded.changed {
+			t.Errorf("expected the index to be thrown away once the settings change, got %+v", loaded)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const DEFAULT_JOURNAL = "journal/{2006}/{01}/{2006-01-02}"
+
+const (
+	JOURNAL_DAILY   = "daily"
+	JOURNAL_ROLLING = "rolling"
+)
+
+// sameDay compares the dates of a and b, ignoring the time
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// journalPath fills in the journal setting for day. only the parts in braces are go time layouts, e.g. {2006-01-02},
+// everything else is kept as is so that names like "Monday notes" or "5 things" aren't mistaken for parts of a date
+func journalPath(journal string, day time.Time) (string, error) {
+	var out strings.Builder
+	rest := journal
+	for {
+		before, after, found := strings.Cut(rest, "{")
+		if strings.Contains(before, "}") {
+			return "", fmt.Errorf("journal `%v` has a } without a {", journal)
+		}
+		out.WriteString(before)
+		if !found {
+			return out.String(), nil
+		}
+		layout, after, found := strings.Cut(after, "}")
+		if !found || strings.Contains(layout, "{") {
+			return "", fmt.Errorf("journal `%v` has a { without a }", journal)
+		}
+		if len(layout) == 0 {
+			return "", fmt.Errorf("journal `%v` has an empty {}", journal)
+		}
+		out.WriteString(day.Format(layout))
+		rest = after
+	}
+}
+
+// OpenJournal makes sure the journal for day exists and returns where to open it. in daily mode that's a note
+// of its own, created from the journal setting. in rolling mode it's the entry for day in a single note, which
+// gets added for today but has to exist already for any other day
+func OpenJournal(day, now time.Time) (filePath string, line int, err error) {
+	journal, err := journalPath(settings.Journal, day)
+	if err != nil {
+		return "", 0, err
+	}
+	filePath, err = notePath(journal)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if settings.JournalMode == JOURNAL_DAILY {
+		if !exists(filePath) {
+			if err := NewNoteFile(filePath, NewNoteOptions{Title: day.Format(settings.DateFormat)}); err != nil {
+				return "", 0, err
+			}
+		}
+		return filePath, 0, nil
+	}
+
+	if !exists(filePath) {
+		if err := NewNoteFile(filePath, NewNoteOptions{}); err != nil {
+			return "", 0, err
+		}
+	}
+	if sameDay(day, now) {
+		if _, err := addTimestamp(filePath, now, EntryOptions{Format: ENTRY_DATE, SkipExisting: true}); err != nil {
+			return "", 0, err
+		}
+	}
+	note, err := readNote(filePath, false)
+	if err != nil {
+		return "", 0, err
+	}
+	for _, entry := range ParseEntries(*note) {
+		if sameDay(entry.Time, day) {
+			return filePath, entry.Line, nil
+		}
+	}
+	return "", 0, fmt.Errorf("%v has no entry for %v", filePath, day.Format(settings.DateFormat))
+}
+
+// set by --print on today, yesterday and day
+var journalPrint bool
+
+func runJournal(day time.Time) {
+	filePath, line, err := OpenJournal(day, time.Now())
+	if err != nil {
+		fmt.Printf("Problem trying to open the journal: %v", err)
+		return
+	}
+	if journalPrint {
+		fmt.Println(filePath)
+		return
+	}
+	if err := EditNoteAt(filePath, line); err != nil {
+		fmt.Printf("Problem trying to edit: %v", err)
+	}
+}
+
+var todayCmd = &cobra.Command{
+	Use:   "today",
+	Short: "opens today's journal, creating it if needed",
+	Long: `opens today's journal in your editor. with journal_mode daily (the default) every day gets its own note at the
+journal path, where the parts in braces are go time layouts filled in with the day and the rest is used as is
+(` + DEFAULT_JOURNAL + ` by default). with journal_mode rolling the journal path is a single note and
+today's entry gets added to it`,
+	Example: "notes today [--print]",
+	Args:    cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		runJournal(time.Now())
+	},
+}
+
+var yesterdayCmd = &cobra.Command{
+	Use:     "yesterday",
+	Short:   "opens yesterday's journal, creating it if needed",
+	Long:    "opens yesterday's journal in your editor, see today. with journal_mode rolling, yesterday needs to already have an entry",
+	Example: "notes yesterday [--print]",
+	Args:    cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		runJournal(time.Now().AddDate(0, 0, -1))
+	},
+}
+
+var dayCmd = &cobra.Command{
+	Use:     "day",
+	Short:   "opens the journal for the given date, creating it if needed",
+	Long:    "opens the journal for the given date in your editor, see today. with journal_mode rolling, the day needs to already have an entry unless it's today",
+	Example: "notes day 2026-10-17 [--print]",
+	Args:    cobra.ExactArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		day, err := parseLogTime(args[0], false)
+		if err != nil {
+			fmt.Printf("Problem trying to read the date: %v", err)
+			return
+		}
+		runJournal(day)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{todayCmd, yesterdayCmd, dayCmd} {
+		cmd.Flags().BoolVarP(&journalPrint, "print", "p", false, "output the journal's path instead of opening it")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenJournal(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ROOT_ENV, root)
+	defer func() { settings = defaultConfig() }()
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.Local)
+
+	filePath, line, err := OpenJournal(now, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(root, "journal", "2026", "10", "2026-10-17.txt"); filePath != want || line != 0 {
+		t.Errorf("expected %v at line 0, got %v at %v", want, filePath, line)
+	}
+	note, err := readNote(filePath, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note.Title != "2026-10-17" {
+		t.Errorf("expected the daily note to be titled with the date, got %v", note.Title)
+	}
+	if err := os.WriteFile(filePath, []byte("title: kept\n------\n"), 0660); err != nil {
+		t.Fatalf("could not write note: %v", err)
+	}
+	if _, _, err := OpenJournal(now, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note, _ := readNote(filePath, true); note == nil || note.Title != "kept" {
+		t.Errorf("expected an existing daily note to be left alone")
+	}
+
+	settings.Journal = "diary"
+	settings.JournalMode = JOURNAL_ROLLING
+	for i := 0; i < 2; i++ {
+		filePath, line, err = OpenJournal(now, now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if line != 5 {
+			t.Errorf("expected today's entry on line 5, got %v", line)
+		}
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("could not read journal: %v", err)
+	}
+	if strings.Count(string(data), "2026-10-17:") != 1 {
+		t.Errorf("expected today's entry to be added once, got:\n%s", data)
+	}
+	if _, _, err := OpenJournal(now.AddDate(0, 0, -1), now); err == nil {
+		t.Errorf("expected an error opening a day without an entry in a rolling journal")
+	}
+}
+
+func TestJournalPath(t *testing.T) {
+	day := time.Date(2026, 10, 5, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		journal string
+		want    string
+		wantErr bool
+	}{
+		{journal: DEFAULT_JOURNAL, want: "journal/2026/10/2026-10-05"},
+		{journal: "Monday notes/5 things {Jan 2}", want: "Monday notes/5 things Oct 5"},
+		{journal: "diary", want: "diary"},
+		{journal: "journal/{2006}/", want: "journal/2026/"},
+		{journal: "journal/{2006", wantErr: true},
+		{journal: "journal/2006}", wantErr: true},
+		{journal: "journal/{}", wantErr: true},
+		{journal: "journal/{{2006}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := journalPath(tt.journal, day)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: unexpected error: %v", tt.journal, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.journal, tt.want, got)
+		}
+	}
+
+	config := defaultConfig()
+	config.Journal = "journal/2006-01-02"
+	if err := config.validate(); err == nil {
+		t.Errorf("expected a daily journal without a date in braces to be rejected")
+	}
+	config.JournalMode = JOURNAL_ROLLING
+	if err := config.validate(); err != nil {
+		t.Errorf("expected a rolling journal to be taken as is, got %v", err)
+	}
+	config.Journal = "journal/{2006"
+	if err := config.validate(); err == nil {
+		t.Errorf("expected an unclosed brace to be rejected")
+	}
+}
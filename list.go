@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// operators are checked in order, so the two character ones need to come first
+var filterOps = []string{"<=", ">=", "!=", "=", "<", ">", "~"}
+
+// NoteFilter compares a header field against a value, e.g. status=open or due<2026-11-01
+type NoteFilter struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+func ParseNoteFilter(input string) (NoteFilter, error) {
+	idx := strings.IndexAny(input, "<>=!~")
+	if idx < 0 {
+		return NoteFilter{}, fmt.Errorf("filter `%v` needs an operator, one of %v", input, strings.Join(filterOps, " "))
+	}
+	for _, op := range filterOps {
+		if !strings.HasPrefix(input[idx:], op) {
+			continue
+		}
+		filter := NoteFilter{
+			Key:   strings.TrimSpace(input[:idx]),
+			Op:    op,
+			Value: strings.TrimSpace(input[idx+len(op):]),
+		}
+		if len(filter.Key) == 0 {
+			return NoteFilter{}, fmt.Errorf("filter `%v` is missing a field name", input)
+		}
+		return filter, nil
+	}
+	return NoteFilter{}, fmt.Errorf("filter `%v` has an unknown operator", input)
+}
+
+// parseValueTime tries the layouts header values are likely to be written in, times without a zone are local
+func parseValueTime(value string) (time.Time, bool) {
+	for _, layout := range []string{JOURNAL_DATE_FORMAT, settings.DateFormat, time.RFC3339Nano, "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareValues orders two header values, as numbers or dates when both look like one, otherwise as text ignoring case
+func compareValues(a, b string) int {
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := parseValueTime(a); ok {
+		if y, ok := parseValueTime(b); ok {
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// Matches checks the filter against a note. tags are matched against each tag rather than the raw header value,
+// and notes missing the field only match != filters
+func (f NoteFilter) Matches(note Note) bool {
+	if strings.EqualFold(f.Key, "tags") {
+		switch f.Op {
+		case "=":
+			return hasAnyTag(note.Tags, []string{f.Value})
+		case "!=":
+			return !hasAnyTag(note.Tags, []string{f.Value})
+		case "~":
+			return (tagTerm{tag: f.Value, mode: MATCH_FUZZY}).Matches(note.Tags)
+		}
+		return false
+	}
+
+	value, ok := note.Fields.Get(f.Key)
+	if !ok {
+		return f.Op == "!="
+	}
+	if f.Op == "~" {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(f.Value))
+	}
+	c := compareValues(value, f.Value)
+	switch f.Op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func FilterNotes(notes []Note, filters []NoteFilter) []Note {
+	results := make([]Note, 0)
+	for _, note := range notes {
+		matches := true
+		for _, filter := range filters {
+			if !filter.Matches(note) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			results = append(results, note)
+		}
+	}
+	return results
+}
+
+// sortValue is what a note gets sorted by. title and mtime always exist, created is the created field
+// falling back to mtime and anything else is a header field
+func sortValue(note Note, by string) (string, bool) {
+	switch by {
+	case "title":
+		return note.Title, true
+	case "created":
+		if created, ok := note.Fields.Get("created"); ok {
+			return created, true
+		}
+		return sortValue(note, "mtime")
+	case "mtime":
+		info, err := os.Stat(note.Path)
+		if err != nil {
+			return "", false
+		}
+		return info.ModTime().Format(time.RFC3339Nano), true
+	}
+	return note.Fields.Get(by)
+}
+
+// SortNotes orders notes by title, mtime, created or any header field. notes without a value always go last
+func SortNotes(notes []Note, by string, reverse bool) {
+	type keyed struct {
+		note  Note
+		value string
+		ok    bool
+	}
+	keys := make([]keyed, len(notes))
+	for i, note := range notes {
+		value, ok := sortValue(note, by)
+		keys[i] = keyed{note: note, value: value, ok: ok}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].ok != keys[j].ok {
+			return keys[i].ok
+		}
+		c := compareValues(keys[i].value, keys[j].value)
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	for i, k := range keys {
+		notes[i] = k.note
+	}
+}
+
+func printNoteTable(notes []Note, field string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	showField := field != "title" && field != "mtime"
+	if showField {
+		fmt.Fprintf(w, "TITLE\tTAGS\t%v\tPATH\n", strings.ToUpper(field))
+	} else {
+		fmt.Fprintf(w, "TITLE\tTAGS\tPATH\n")
+	}
+	for _, note := range notes {
+		tags := strings.Join(note.Tags, ", ")
+		if showField {
+			value, _ := sortValue(note, field)
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", note.Title, tags, value, note.Path)
+		} else {
+			fmt.Fprintf(w, "%v\t%v\t%v\n", note.Title, tags, note.Path)
+		}
+	}
+	w.Flush()
+}
+
+var (
+	listWhere   []string
+	listSort    string
+	listReverse bool
+	listLimit   int
+	listSelect  bool
+)
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "lists notes, filtered and sorted by their header fields",
+	Long: `lists notes, filtered and sorted by their header fields. filters compare a field to a value with
+one of = != < <= > >= or ~ (contains), values that look like numbers or dates are compared as such.
+tags=x matches notes with the tag x. --select picks one of the listed notes interactively and outputs its path`,
+	Example: "notes list --where status=open --where 'due<2026-11-01' --sort due --limit 10",
+	Args:    cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		filters := make([]NoteFilter, len(listWhere))
+		for i, where := range listWhere {
+			filter, err := ParseNoteFilter(where)
+			if err != nil {
+				fmt.Printf("Problem trying to list notes: %v", err)
+				return
+			}
+			filters[i] = filter
+		}
+		notes, err := collectFiles(true)
+		if err != nil {
+			fmt.Printf("Problem trying to list notes: %v", err)
+			return
+		}
+		notes = FilterNotes(notes, filters)
+		SortNotes(notes, listSort, listReverse)
+		if listLimit > 0 && len(notes) > listLimit {
+			notes = notes[:listLimit]
+		}
+
+		if listSelect {
+			mod, err := NewNoteSelector("Select Note", notes)
+			if err != nil {
+				fmt.Printf("Could not select a file: %v", err)
+				return
+			}
+			choice, err := runSelector(mod)
+			if err != nil {
+				fmt.Printf("Could not select a file: %v", err)
+				return
+			}
+			fmt.Println(choice.Path)
+			return
+		}
+		if outputFormat == OUTPUT_TEXT {
+			printNoteTable(notes, listSort)
+			return
+		}
+		if err := writeOutput(noteRecords(notes, false), nil); err != nil {
+			fmt.Printf("Problem trying to output notes: %v", err)
+		}
+	},
+}
+
+func init() {
+	listCmd.Flags().StringArrayVarP(&listWhere, "where", "w", nil, "only list notes where a field matches, can be repeated")
+	listCmd.Flags().StringVarP(&listSort, "sort", "s", "title", "sort by title, mtime, created or any header field")
+	listCmd.Flags().BoolVarP(&listReverse, "reverse", "r", false, "reverse the sort order")
+	listCmd.Flags().IntVarP(&listLimit, "limit", "n", 0, "only list this many notes")
+	listCmd.Flags().BoolVar(&listSelect, "select", false, "pick from the listed notes interactively")
+}
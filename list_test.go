@@ -0,0 +1,95 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNoteFilter(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    NoteFilter
+		wantErr bool
+	}{
+		{input: "status=open", want: NoteFilter{Key: "status", Op: "=", Value: "open"}},
+		{input: "due <= 2026-11-01", want: NoteFilter{Key: "due", Op: "<=", Value: "2026-11-01"}},
+		{input: "status!=done", want: NoteFilter{Key: "status", Op: "!=", Value: "done"}},
+		{input: "title~plan", want: NoteFilter{Key: "title", Op: "~", Value: "plan"}},
+		{input: "status", wantErr: true},
+		{input: "=open", wantErr: true},
+		{input: "status!open", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNoteFilter(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expected error parsing %q", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("filter mismatch for %q:\nexpected: %+v\ngot: %+v", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestFilterAndSortNotes(t *testing.T) {
+	notes := []Note{
+		{Title: "b", Tags: []string{"work"}, Fields: Fields{{Key: "status", Value: "open"}, {Key: "due", Value: "2026-10-20"}, {Key: "priority", Value: "10"}}},
+		{Title: "a", Tags: []string{"home"}, Fields: Fields{{Key: "Status", Value: "Open"}, {Key: "due", Value: "2026-12-01"}, {Key: "priority", Value: "9"}}},
+		{Title: "c", Tags: []string{"work"}, Fields: Fields{{Key: "status", Value: "done"}, {Key: "due", Value: "2026-09-01"}}},
+		{Title: "d"},
+	}
+	titles := func(notes []Note) []string {
+		result := make([]string, len(notes))
+		for i, n := range notes {
+			result[i] = n.Title
+		}
+		return result
+	}
+	filter := func(inputs ...string) []Note {
+		filters := make([]NoteFilter, len(inputs))
+		for i, input := range inputs {
+			f, err := ParseNoteFilter(input)
+			if err != nil {
+				t.Fatalf("unexpected error parsing %q: %v", input, err)
+			}
+			filters[i] = f
+		}
+		return FilterNotes(notes, filters)
+	}
+
+	if got := titles(filter("status=open")); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("status=open mismatch: %v", got)
+	}
+	if got := titles(filter("status=open", "due<2026-11-01")); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("date filter mismatch: %v", got)
+	}
+	if got := titles(filter("priority>9")); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("numeric filter mismatch: %v", got)
+	}
+	if got := titles(filter("status!=done")); !reflect.DeepEqual(got, []string{"b", "a", "d"}) {
+		t.Errorf("!= filter mismatch: %v", got)
+	}
+	if got := titles(filter("tags=work")); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("tag filter mismatch: %v", got)
+	}
+
+	sorted := append([]Note{}, notes...)
+	SortNotes(sorted, "due", false)
+	if got := titles(sorted); !reflect.DeepEqual(got, []string{"c", "b", "a", "d"}) {
+		t.Errorf("sort by due mismatch: %v", got)
+	}
+	SortNotes(sorted, "priority", true)
+	if got := titles(sorted); !reflect.DeepEqual(got, []string{"b", "a", "c", "d"}) {
+		t.Errorf("reverse sort by priority mismatch: %v", got)
+	}
+	SortNotes(sorted, "title", false)
+	if got := titles(sorted); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("sort by title mismatch: %v", got)
+	}
+}
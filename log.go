@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Entry is one dated section of a note, as written by notes entry
+type Entry struct {
+	Path  string `json:"path"`
+	Title string `json:"title"`
+	// line number of the entry's stamp within the file
+	Line    int       `json:"line"`
+	Time    time.Time `json:"time"`
+	Stamp   string    `json:"stamp"`
+	Heading string    `json:"heading"`
+	Body    string    `json:"body"`
+}
+
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// parseEntryStamp reads a stamp back into a time, trying the entry formats before the usual date layouts.
+// weeks start on their monday
+func parseEntryStamp(stamp string) (time.Time, bool) {
+	if m := isoWeekPattern.FindStringSubmatch(stamp); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		if week < 1 || week > 53 {
+			return time.Time{}, false
+		}
+		// the fourth of january is always in the first week
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+		monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+		return monday.AddDate(0, 0, (week-1)*7), true
+	}
+	layouts := []string{settings.DateFormat + " 15:04", settings.DateFormat}
+	switch settings.EntryFormat {
+	case ENTRY_DATE, ENTRY_DATETIME, ENTRY_WEEK:
+	default:
+		layouts = append([]string{settings.EntryFormat}, layouts...)
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, stamp, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return parseValueTime(stamp)
+}
+
+// parseEntryLine checks whether line starts an entry, i.e. is a stamp followed by a colon and an optional heading
+func parseEntryLine(line string) (ts time.Time, stamp, heading string, ok bool) {
+	line = strings.TrimRight(line, "\r")
+	if len(line) == 0 || line[0] == ' ' || line[0] == '\t' {
+		return time.Time{}, "", "", false
+	}
+	if strings.HasSuffix(line, ":") {
+		stamp = strings.TrimSuffix(line, ":")
+		if ts, ok := parseEntryStamp(stamp); ok {
+			return ts, stamp, "", true
+		}
+	}
+	// stamps with a time have colons of their own, so every ": " has to be tried
+	for i := strings.Index(line, ": "); i >= 0; {
+		stamp = line[:i]
+		if ts, ok := parseEntryStamp(stamp); ok {
+			return ts, stamp, strings.TrimSpace(line[i+2:]), true
+		}
+		next := strings.Index(line[i+2:], ": ")
+		if next < 0 {
+			break
+		}
+		i += 2 + next
+	}
+	return time.Time{}, "", "", false
+}
+
+// ParseEntries splits the content of a note into its entries, anything before the first entry is left out
+func ParseEntries(note Note) []Entry {
+	offset := note.contentLine()
+	entries := make([]Entry, 0)
+	var body []string
+	finish := func() {
+		if len(entries) == 0 {
+			return
+		}
+		entries[len(entries)-1].Body = strings.Trim(strings.Join(body, "\n"), "\r\n")
+	}
+	for i, line := range strings.Split(note.Content, "\n") {
+		ts, stamp, heading, ok := parseEntryLine(line)
+		if !ok {
+			body = append(body, line)
+			continue
+		}
+		finish()
+		body = nil
+		entries = append(entries, Entry{
+			Path:    note.Path,
+			Title:   note.Title,
+			Line:    offset + i + 1,
+			Time:    ts,
+			Stamp:   stamp,
+			Heading: heading,
+		})
+	}
+	finish()
+	return entries
+}
+
+// parseLogTime reads a --since or --until value. a plain date covers the whole day, so --until includes it
+func parseLogTime(value string, endOfDay bool) (time.Time, error) {
+	t, ok := parseValueTime(value)
+	if !ok {
+		return time.Time{}, fmt.Errorf("could not read `%v` as a date, expected something like %v", value, time.Now().Format(JOURNAL_DATE_FORMAT))
+	}
+	if endOfDay && t.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())) {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// FilterEntries keeps the entries between since and until, inclusive. a zero time leaves that side open
+func FilterEntries(entries []Entry, since, until time.Time) []Entry {
+	results := make([]Entry, 0)
+	for _, entry := range entries {
+		if !since.IsZero() && entry.Time.Before(since) {
+			continue
+		}
+		if !until.IsZero() && entry.Time.After(until) {
+			continue
+		}
+		results = append(results, entry)
+	}
+	return results
+}
+
+// SortEntries puts entries in chronological order, entries from the same time stay in the order they're written
+func SortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Time.Before(entries[j].Time)
+		}
+		return entries[i].Path < entries[j].Path
+	})
+}
+
+func printEntry(entry Entry) {
+	heading := ""
+	if len(entry.Heading) > 0 {
+		heading = "  " + entry.Heading
+	}
+	fmt.Printf("%v  %v:%v%v\n", entry.Stamp, entry.Path, entry.Line, heading)
+	if len(entry.Body) > 0 {
+		for _, line := range strings.Split(entry.Body, "\n") {
+			if line = strings.TrimRight(line, "\r"); len(line) == 0 {
+				fmt.Println()
+				continue
+			}
+			fmt.Printf("    %v\n", line)
+		}
+	}
+	fmt.Println()
+}
+
+var (
+	logSince string
+	logUntil string
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "lists journal entries in chronological order",
+	Long: `lists the dated entries added with notes entry in chronological order, from the given note or from every note.
+--since and --until take a date (or a date and time) and include the day they name. entries are found by their stamps,
+so ones written with a --format layout other than the entry_format setting aren't picked up`,
+	Example: "notes log [note] [--since 2026-10-01] [--until 2026-10-31]",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		return optionalExistingNote(cmd, args)
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		var since, until time.Time
+		var err error
+		if len(logSince) > 0 {
+			if since, err = parseLogTime(logSince, false); err != nil {
+				fmt.Printf("Problem trying to read --since: %v", err)
+				return
+			}
+		}
+		if len(logUntil) > 0 {
+			if until, err = parseLogTime(logUntil, true); err != nil {
+				fmt.Printf("Problem trying to read --until: %v", err)
+				return
+			}
+		}
+
+		var notes []Note
+		if len(args) == 1 {
+			note, err := readNote(args[0], false)
+			if err != nil {
+				fmt.Printf("Problem trying to read note: %v", err)
+				return
+			}
+			notes = []Note{*note}
+		} else {
+			notes, err = collectFiles(false)
+			if err != nil {
+				fmt.Printf("Problem getting files: %v", err)
+				return
+			}
+		}
+
+		entries := make([]Entry, 0)
+		for _, note := range notes {
+			entries = append(entries, ParseEntries(note)...)
+		}
+		entries = FilterEntries(entries, since, until)
+		SortEntries(entries)
+		if err := writeOutput(entries, printEntry); err != nil {
+			fmt.Printf("Problem trying to output entries: %v", err)
+		}
+	},
+}
+
+func init() {
+	logCmd.Flags().StringVar(&logSince, "since", "", "only list entries from this date on")
+	logCmd.Flags().StringVar(&logUntil, "until", "", "only list entries up to and including this date")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEntries(t *testing.T) {
+	content := `some notes before any entry
+
+2026-10-17 09:30: standup
+moved the release
+to friday
+
+2026-10-16:
+  2026-10-01: indented, so not an entry
+note: not a stamp either
+
+2026-W40:
+week entry
+`
+	note, err := ParseNote(strings.NewReader("title: log\ntags:\n------\n"+content), "log.txt", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries := ParseEntries(*note)
+	want := []Entry{
+		{Line: 6, Time: time.Date(2026, 10, 17, 9, 30, 0, 0, time.Local), Stamp: "2026-10-17 09:30", Heading: "standup", Body: "moved the release\nto friday"},
+		{Line: 10, Time: time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local), Stamp: "2026-10-16", Body: "  2026-10-01: indented, so not an entry\nnote: not a stamp either"},
+		{Line: 14, Time: time.Date(2026, 9, 28, 0, 0, 0, 0, time.Local), Stamp: "2026-W40", Body: "week entry"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %v entries, got %v: %+v", len(want), len(entries), entries)
+	}
+	for i, entry := range entries {
+		w := want[i]
+		w.Path = "log.txt"
+		w.Title = "log"
+		if entry.Line != w.Line || !entry.Time.Equal(w.Time) || entry.Stamp != w.Stamp || entry.Heading != w.Heading ||
+			entry.Body != w.Body || entry.Path != w.Path || entry.Title != w.Title {
+			t.Errorf("entry %v mismatch:\nexpected: %+v\ngot: %+v", i, w, entry)
+		}
+	}
+
+	SortEntries(entries)
+	if entries[0].Stamp != "2026-W40" || entries[2].Stamp != "2026-10-17 09:30" {
+		t.Errorf("expected entries in chronological order, got %v, %v, %v", entries[0].Stamp, entries[1].Stamp, entries[2].Stamp)
+	}
+
+	since, err := parseLogTime("2026-10-16", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	until, err := parseLogTime("2026-10-16", true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filtered := FilterEntries(entries, since, until)
+	if len(filtered) != 1 || filtered[0].Stamp != "2026-10-16" {
+		t.Errorf("expected only the 2026-10-16 entry, got %+v", filtered)
+	}
+	if filtered := FilterEntries(entries, since, time.Time{}); len(filtered) != 2 {
+		t.Errorf("expected an open until to keep later entries, got %+v", filtered)
+	}
+	if _, err := parseLogTime("last tuesday", false); err == nil {
+		t.Errorf("expected an error for an unreadable date")
+	}
+}
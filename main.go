@@ -3,15 +3,15 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/sahilm/fuzzy"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +22,7 @@ var (
 	date   = "20XX-01-01"
 )
 
+// defaults for when the config doesn't say otherwise
 const DIVIDER = "------"
 const JOURNAL_DATE_FORMAT = "2006-01-02"
 
@@ -30,34 +31,58 @@ type Note struct {
 	Title   string
 	Tags    []string
 	Content string
+	// every key: value line of the header, including title and tags
+	Fields Fields
 	// header may include metadata that's not necessarily tracked in this struct
 	rawHeader string
+	// why the file couldn't be parsed as a note, set when it was read as an untitled note instead
+	headerErr error
 }
 
-//func (i Note) Title() string       { return i.Title }
+// contentLine is the line of the file that the content starts on, counting from 0
+func (i Note) contentLine() int {
+	if i.headerErr != nil {
+		return 0
+	}
+	// content starts on the line after the divider
+	return strings.Count(i.rawHeader, "\n") + 1
+}
+
+// func (i Note) Title() string       { return i.Title }
 func (i Note) Description() string { return strings.Join(i.Tags, ", ") }
 func (i Note) FilterValue() string {
 	return fmt.Sprintf("%s%s%s", i.Title, i.Path, strings.Join(i.Tags, ""))
 }
 
-func addTimestamp(file *os.File, path string, ts time.Time) error {
-	note, err := ParseNote(file, path, false)
+// addTimestamp adds an entry to the note, existing is true when the note already had an entry for ts and SkipExisting was set
+func addTimestamp(path string, ts time.Time, opts EntryOptions) (existing bool, err error) {
+	note, err := readNote(path, true)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if note.headerErr != nil {
+		return false, fmt.Errorf("can not add an entry to a file without a valid header: %w", note.headerErr)
+	}
+	order, err := entryOrder(note.Fields, opts.Order)
+	if err != nil {
+		return false, err
 	}
 
-	note.Content = fmt.Sprintf("%v:\n\n\n%v", ts.Format(JOURNAL_DATE_FORMAT), note.Content)
-	out := []byte(fmt.Sprintf("%v%v\n\n%v", note.rawHeader, DIVIDER, note.Content))
-	file.Truncate(0)
-	wrote := 0
-	for written := 0; written <= len(out)-1; written += wrote {
-		wrote, err = file.Write(out[written:])
-
-		if err != nil {
-			return fmt.Errorf("problem writing to file: %w", err)
-		}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("could not read file: %v, %w", path, err)
 	}
-	return nil
+	header, content, ok := formatFor(path).Split(data)
+	if !ok {
+		return false, fmt.Errorf("could not find the end of the header in %v", path)
+	}
+	var newContent string
+	newContent, existing = addEntry(string(content), ts, order, opts)
+	out := []byte(fmt.Sprintf("%s\n%v", header, newContent))
+	if err := writeNoteFile(path, out); err != nil {
+		return false, err
+	}
+	return existing, nil
 }
 
 func exists(path string) bool {
@@ -65,38 +90,151 @@ func exists(path string) bool {
 	return !errors.Is(err, os.ErrNotExist)
 }
 
-func NewNoteFile(filePath string) error {
+type NewNoteOptions struct {
+	// name of a template in the notebook's templates directory, empty for the default header
+	Template string
+	// replaces the title from the file name or template
+	Title string
+	// added to any tags the template already has
+	Tags []string
+	// written after the header, following anything the template puts there
+	Body string
+}
+
+// newNoteContents is everything that goes into a new note at filePath
+func newNoteContents(filePath string, opts NewNoteOptions) (string, error) {
+	format := formatFor(filePath)
+	title := noteName(filePath)
+	rendered, err := format.Render(Fields{{Key: "title", Value: title}, {Key: "tags"}}, "")
+	if err != nil {
+		return "", err
+	}
+	contents := string(rendered)
+	if len(opts.Template) > 0 {
+		root, err := notesRoot()
+		if err != nil {
+			return "", err
+		}
+		now := time.Now()
+		contents, err = RenderTemplate(root, opts.Template, TemplateData{
+			Title:  title,
+			Date:   now.Format(settings.DateFormat),
+			Now:    now,
+			Author: currentAuthor(),
+			Path:   indexKey(root, filePath),
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(opts.Title) > 0 {
+		out, err := format.SetField([]byte(contents), "title", opts.Title)
+		if err != nil {
+			return "", err
+		}
+		contents = string(out)
+	}
+	if len(opts.Tags) > 0 {
+		note, err := format.Parse(strings.NewReader(contents), filePath, true)
+		if err != nil {
+			return "", err
+		}
+		tags := uniqueTags(append(note.Tags, opts.Tags...))
+		out, err := format.SetField([]byte(contents), "tags", strings.Join(tags, ", "))
+		if err != nil {
+			return "", err
+		}
+		contents = string(out)
+	}
+	if len(opts.Body) > 0 {
+		if !strings.HasSuffix(contents, "\n") {
+			contents += "\n"
+		}
+		contents += opts.Body
+		if !strings.HasSuffix(contents, "\n") {
+			contents += "\n"
+		}
+	}
+	return contents, nil
+}
+
+func NewNoteFile(filePath string, opts NewNoteOptions) error {
+	contents, err := newNoteContents(filePath, opts)
+	if err != nil {
+		return err
+	}
+
 	if err := os.MkdirAll(filepath.Dir(filePath), 0770); err != nil {
 		return err
 	}
 
-	f, err := os.Create(filePath)
-	if err != nil {
+	if err := writeFileAtomic(filePath, []byte(contents), 0666); err != nil {
 		return fmt.Errorf("could not create file (at path: %v): %w", filePath, err)
 	}
-	initialName := path.Base(filePath)
-
-	fmt.Fprintf(f, `title: %v
-tags:
-%v
-`, strings.TrimSuffix(initialName, ".txt"), DIVIDER,
-	)
-	defer f.Close()
 	return nil
 }
 
-func checkExistance(userInput string, wantExistance bool) (string, error) {
+// writeNoteFile replaces the contents of an existing note, keeping its permissions. the old contents are
+// backed up first and the new ones written atomically, so the note survives being interrupted part way
+func writeNoteFile(filePath string, data []byte) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("could not stat file: %v, %w", filePath, err)
+	}
+	if err := backupNote(filePath); err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, data, info.Mode().Perm())
+}
+
+// notePath resolves what the user typed to a note's path, adding the extension and putting relative paths in the notebook
+func notePath(userInput string) (string, error) {
 	if len(userInput) == 0 {
 		return "", fmt.Errorf("empty filename")
 	}
-	if !strings.HasSuffix(userInput, ".txt") {
-		userInput += ".txt"
+	if !filepath.IsAbs(userInput) {
+		root, err := notesRoot()
+		if err != nil {
+			return "", err
+		}
+		userInput = path.Join(root, userInput)
+	}
+	if _, ok := noteExtension(userInput); ok {
+		return userInput, nil
+	}
+	// without an extension it's whichever note exists, defaulting to the extension setting
+	for _, ext := range append([]string{settings.Extension}, noteExtensions()...) {
+		if exists(userInput + ext) {
+			return userInput + ext, nil
+		}
+	}
+	return userInput + settings.Extension, nil
+}
+
+// cleanNotebookPath cleans a path relative to the notebook, refusing anything that leads outside of it or into
+// its metadata directory. the result has / separators
+func cleanNotebookPath(relPath string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(relPath))
+	if path.IsAbs(clean) || filepath.IsAbs(relPath) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("`%v` is outside of the notebook", relPath)
+	}
+	if clean == "." {
+		return "", fmt.Errorf("`%v` is the notebook itself, not a note in it", relPath)
 	}
-	curDir, err := os.Getwd()
+	if clean == METADATA_DIR || strings.HasPrefix(clean, METADATA_DIR+"/") {
+		return "", fmt.Errorf("`%v` is in the notebook's %v directory", relPath, METADATA_DIR)
+	}
+	return clean, nil
+}
+
+func checkExistance(userInput string, wantExistance bool) (string, error) {
+	// args are checked before the config error would otherwise be reported
+	requireConfig()
+	outPath, err := notePath(userInput)
 	if err != nil {
-		return "", fmt.Errorf("could not get working directory: %w", err)
+		return "", err
 	}
-	outPath := path.Join(curDir, userInput)
 	if exists(outPath) != wantExistance {
 		var message string
 		if wantExistance {
@@ -109,52 +247,78 @@ func checkExistance(userInput string, wantExistance bool) (string, error) {
 	return outPath, nil
 }
 
-// TODO: hmmm maybe a validate files command?
-//func CheckNote(c *cli.Context) error {
-//	name := c.Args().First()
-//	outPath, err := checkExistance(name, true)
-//	if err != nil {
-//		return err
-//	}
-//
-//	file, err := os.Open(outPath)
-//	if err != nil {
-//		return fmt.Errorf("could not open file: %v, %w", outPath, err)
-//	}
-//	defer file.Close()
-//
-//	note, err := ParseNote(file, outPath, false)
-//	if err != nil {
-//		return fmt.Errorf("could not parse file: %w", err)
-//	}
-//	fmt.Printf("loaded file: \n「%v」\n%+q\n%v\n", note.Title, note.Tags, note.Content)
-//
-//	return nil
-//}
-
-func CatNote(filePath string) error {
-	file, err := os.Open(filePath)
+// noteArgs resolves the paths given to a command against the notebook, directories give every note in them
+// and no args gives the whole notebook
+func noteArgs(args []string) ([]string, error) {
+	root, err := notesRoot()
 	if err != nil {
-		return fmt.Errorf("could not open file: %v, %w", filePath, err)
+		return nil, err
+	}
+	if len(args) == 0 {
+		return files(root), nil
+	}
+	results := make([]string, 0)
+	for _, arg := range args {
+		dir := arg
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, dir)
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			results = append(results, files(dir)...)
+			continue
+		}
+		filePath, err := checkExistance(arg, true)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, filePath)
 	}
-	defer file.Close()
+	return results, nil
+}
 
-	note, err := ParseNote(file, filePath, false)
+// optionalExistingNote resolves the first arg to an existing note, no args means the note gets picked interactively
+func optionalExistingNote(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	preparedFileName, err := checkExistance(args[0], true)
 	if err != nil {
-		return fmt.Errorf("could not parse file: %w", err)
+		return err
 	}
-	fmt.Printf("%v", note.Content)
+	args[0] = preparedFileName
+	cmd.SetArgs(args)
 
 	return nil
 }
 
+// readNote reads a single note, a file without a valid header is read as an untitled note unless parsing strictly
+func readNote(filePath string, justHeader bool) (*Note, error) {
+	note, err := parseNoteFile(filePath, justHeader)
+	if err != nil {
+		return nil, fmt.Errorf("%v, %w", filePath, err)
+	}
+	if strictParse && note.headerErr != nil {
+		return nil, fmt.Errorf("could not parse file: %w", note.headerErr)
+	}
+	return note, nil
+}
+
+func CatNote(note Note) error {
+	return writeOutput([]NoteRecord{NewNoteRecord(note, true)}, func(record NoteRecord) {
+		fmt.Printf("%v", *record.Content)
+	})
+}
+
 func files(dir string) []string {
 	results := make([]string, 0)
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if strings.HasSuffix(strings.ToLower(info.Name()), ".txt") {
+		if info.IsDir() && info.Name() == METADATA_DIR {
+			return filepath.SkipDir
+		}
+		if _, ok := noteExtension(info.Name()); ok && !info.IsDir() {
 			results = append(results, path)
 		}
 		return nil
@@ -165,74 +329,51 @@ func files(dir string) []string {
 	return results
 }
 
-func CheckTags(input []string) error {
-	curDir, err := os.Getwd()
+// CheckTags finds the notes whose tags match the query
+func CheckTags(query TagQuery, withContent bool) ([]Note, error) {
+	notes, err := collectFiles(!withContent)
 	if err != nil {
-		return fmt.Errorf("could not get working directory: %w", err)
-	}
-	fileList := files(curDir)
-	wg := &sync.WaitGroup{}
-	searchTag := strings.Join(input, " ")
-
-	wg.Add(10)
-	in := make(chan string, 10)
-	out := make(chan Note, len(fileList))
-	for i := 0; i < 10; i++ {
-		go parseWorker(in, out, wg, true, false)
-	}
-	for _, fileName := range fileList {
-		in <- fileName
+		return nil, err
 	}
-	close(in)
 
-	wg.Wait()
-	close(out)
-	for result := range out {
-		results := fuzzy.Find(searchTag, result.Tags)
-		if results.Len() > 0 {
-			fmt.Printf("%v : %v\n", result.Title, result.Path)
+	results := make([]Note, 0)
+	for _, note := range notes {
+		if query.Matches(note.Tags) {
+			results = append(results, note)
 		}
 	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
 
-	return nil
+	return results, nil
 }
 
-func parseWorker(in chan string, out chan Note, wg *sync.WaitGroup, justHeader, outputErrors bool) {
+type parseResult struct {
+	note    *Note
+	skipped *SkippedFile
+}
+
+func parseWorker(in chan string, out chan parseResult, wg *sync.WaitGroup, justHeader bool) {
 	for filename := range in {
-		f, err := os.Open(filename)
+		note, err := parseNoteFile(filename, justHeader)
 		if err != nil {
-			if outputErrors {
-				fmt.Printf("could not open file: %v", err)
-			}
+			out <- parseResult{skipped: &SkippedFile{Path: filename, Reason: err.Error()}}
 			continue
 		}
-		note, err := ParseNote(f, filename, justHeader)
-		if err != nil {
-			if outputErrors {
-				fmt.Printf("could not parse file: %v", err)
-			}
-			continue
-		}
-		f.Close()
-
-		out <- *note
+		out <- parseResult{note: note}
 	}
 	wg.Done()
 }
 
-func collectFiles(justHeader, outputFileErrors bool) ([]Note, error) {
-	curDir, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("could not get working directory: %w", err)
-	}
-	fileList := files(curDir)
+// parseFiles reads every file in fileList, files without a valid header are included as untitled notes
+// and files that can't be read as notes at all are returned as skipped
+func parseFiles(fileList []string, justHeader bool) ([]Note, []SkippedFile) {
 	wg := &sync.WaitGroup{}
 
 	wg.Add(10)
 	in := make(chan string, 10)
-	out := make(chan Note, len(fileList))
+	out := make(chan parseResult, len(fileList))
 	for i := 0; i < 10; i++ {
-		go parseWorker(in, out, wg, justHeader, outputFileErrors)
+		go parseWorker(in, out, wg, justHeader)
 	}
 	for _, fileName := range fileList {
 		in <- fileName
@@ -241,14 +382,65 @@ func collectFiles(justHeader, outputFileErrors bool) ([]Note, error) {
 
 	wg.Wait()
 	close(out)
-	results := make([]Note, len(fileList))
-	c := 0
+	results := make([]Note, 0, len(fileList))
+	skipped := make([]SkippedFile, 0)
 	for result := range out {
-		results[c] = result
-		c++
+		if result.skipped != nil {
+			skipped = append(skipped, *result.skipped)
+			continue
+		}
+		results = append(results, *result.note)
 	}
 
-	return results, nil
+	return results, skipped
+}
+
+// every file left out by collectFiles during this run, summarized once the command is done
+var skippedFiles []SkippedFile
+
+// collectFiles parses every note in the notebook. header only collection goes through the index
+// so that only files that changed since the last run need to be parsed
+func collectFiles(justHeader bool) ([]Note, error) {
+	root, err := notesRoot()
+	if err != nil {
+		return nil, err
+	}
+	fileList := files(root)
+	var notes []Note
+	var skipped []SkippedFile
+	if justHeader {
+		idx := loadIndex(root)
+		notes, skipped = idx.refresh(root, fileList)
+		if idx.changed {
+			// the index is only a cache, so not being able to write it shouldn't stop anything
+			_ = idx.save(root)
+		}
+	} else {
+		notes, skipped = parseFiles(fileList, false)
+	}
+	notes, strictSkipped := strictNotes(notes)
+	skippedFiles = append(skippedFiles, append(skipped, strictSkipped...)...)
+	return notes, nil
+}
+
+// printSkippedFiles lists the files that were left out, on stderr so it doesn't get mixed into json output
+func printSkippedFiles() {
+	if len(skippedFiles) == 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	unique := make([]SkippedFile, 0, len(skippedFiles))
+	for _, skipped := range skippedFiles {
+		if !seen[skipped.Path] {
+			seen[skipped.Path] = true
+			unique = append(unique, skipped)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i].Path < unique[j].Path })
+	fmt.Fprintf(os.Stderr, "\nskipped %v file(s) that could not be read as notes:\n", len(unique))
+	for _, skipped := range unique {
+		fmt.Fprintf(os.Stderr, "  %v: %v\n", skipped.Path, skipped.Reason)
+	}
 }
 
 var catCmd = &cobra.Command{
@@ -256,124 +448,194 @@ var catCmd = &cobra.Command{
 	Example: "notes cat [filepath]",
 	Short:   "output the contents of a note",
 	Long:    "output the contents of a note. if no note is specified, it goes into an interactive mode to select a note.",
-	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return nil
-		}
-		preparedFileName, err := checkExistance(args[0], true)
-		if err != nil {
-			return err
-		}
-		args[0] = preparedFileName
-		cmd.SetArgs(args)
-
-		return nil
-	},
+	Args:    optionalExistingNote,
 	Run: func(_ *cobra.Command, args []string) {
+		var note *Note
 		if len(args) == 0 {
-			mod, err := NewFileSelector("Select File to Add an Entry to", true)
+			choice, err := SelectNote("Select File to Output", false)
 			if err != nil {
 				fmt.Printf("Could not select a file: %v", err)
 				return
 			}
-			m, err := tea.NewProgram(mod).StartReturningModel()
+			note = &choice
+		} else {
+			var err error
+			note, err = readNote(args[0], false)
 			if err != nil {
-				fmt.Printf("Problem trying to get selection: %v", err)
-				return
-			}
-			mod, ok := m.(model)
-			if !ok {
-				fmt.Println("Could not read selection")
+				fmt.Printf("Problem trying to cat: %v", err)
 				return
 			}
-			fmt.Printf("%s", mod.choice.Content)
-			return
-
 		}
-		if err := CatNote(args[0]); err != nil {
+		if err := CatNote(*note); err != nil {
 			fmt.Printf("Problem trying to cat: %v", err)
 		}
 	},
 }
 
+var (
+	taggedContent bool
+	taggedMatch   string
+)
+
 var checkTagsCmd = &cobra.Command{
 	Use:   "tagged",
 	Short: "lists out files that match the tag",
-	Args:  cobra.MinimumNArgs(1),
+	Long: `lists out files whose tags match the query. terms next to each other must all match,
++term is the same as term, -term and NOT term exclude notes with the tag, OR matches either side
+and parentheses group. a term may be written as tag:name.
+flags go before the query, everything from the first term on is read as the query. a query that starts
+with an exclusion, or excludes a tag named like a short flag such as -o, needs -- in front of it so it isn't
+read as a flag`,
+	Example: `notes tagged work +urgent -archived
+notes tagged --match exact 'tag:a OR (tag:b NOT c)'
+notes tagged -- -archived`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		// anything after -- is the query, even if it looks like a flag
+		dash := cmd.ArgsLenAtDash()
+		for i, arg := range args {
+			if dash >= 0 && i >= dash {
+				break
+			}
+			shorthand := len(arg) == 2 && arg[0] == '-' && cmd.Flags().ShorthandLookup(arg[1:]) != nil
+			if strings.HasPrefix(arg, "--") || shorthand {
+				return fmt.Errorf("flags go before the query, got `%v` after it", arg)
+			}
+		}
+		return nil
+	},
 	Run: func(_ *cobra.Command, args []string) {
-		if err := CheckTags(args[0:]); err != nil {
+		query, err := ParseTagQuery(strings.Join(args, " "), taggedMatch)
+		if err != nil {
 			fmt.Printf("Problem trying to check for tags: %v", err)
+			return
+		}
+		notes, err := CheckTags(query, taggedContent)
+		if err != nil {
+			fmt.Printf("Problem trying to check for tags: %v", err)
+			return
+		}
+		err = writeOutput(noteRecords(notes, taggedContent), func(record NoteRecord) {
+			fmt.Printf("%v : %v\n", record.Title, record.Path)
+		})
+		if err != nil {
+			fmt.Printf("Problem trying to output tagged notes: %v", err)
 		}
 	},
 }
 
+var (
+	entryTime         bool
+	entryFormat       string
+	entryHeading      string
+	entryMessage      string
+	entryOrderFlag    string
+	entrySkipExisting bool
+)
+
 var newEntryCmd = &cobra.Command{
 	Use:     "entry",
 	Aliases: []string{"e"},
-	Short:   "adds today's YYYY-MM-DD date at the top of the provided note",
-	Long:    "adds today's YYYY-MM-DD date at the top of the provided note. if no note is specified, it goes into an interactive mode to select one.",
-	Example: "notes entry [directory/file]",
+	Short:   "adds a dated entry to the provided note",
+	Long: `adds a dated entry to the provided note, at the top or the bottom depending on its entry order. if no note is specified, it goes into an interactive mode to select one.
+entries are stamped with the entry_format setting, which can be date, datetime, week (the ISO week) or a go time layout.
+--time is short for --format datetime. --message writes the entry's text, --message - reads it from stdin.
+entries go at the top of the note unless its header has "` + ENTRY_ORDER_FIELD + `: ` + ENTRY_APPEND + `" or --order says otherwise.
+--skip-existing doesn't add another heading when the note already has an entry with the same stamp,
+adding the message to that entry instead`,
+	Example: `notes entry [directory/file]
+notes entry journal --time --heading standup --message "moved the release to friday"`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
-			return nil
+		if len(args) == 0 && entryMessage == "-" {
+			return fmt.Errorf("a note is needed when reading the message from stdin")
 		}
-		preparedFileName, err := checkExistance(args[0], true)
-		if err != nil {
-			return err
-		}
-		args[0] = preparedFileName
-		cmd.SetArgs(args)
-
-		return nil
+		return optionalExistingNote(cmd, args)
 	},
 	Run: func(_ *cobra.Command, args []string) {
-		var selectedFile string
-		if len(args) == 0 {
-			mod, err := NewFileSelector("Select File to Add a Date Entry to", true)
+		opts := EntryOptions{
+			Format:       entryFormat,
+			Heading:      entryHeading,
+			Message:      entryMessage,
+			Order:        entryOrderFlag,
+			SkipExisting: entrySkipExisting,
+		}
+		if entryTime {
+			opts.Format = ENTRY_DATETIME
+		}
+		if len(opts.Format) == 0 {
+			opts.Format = settings.EntryFormat
+		}
+		if entryMessage == "-" {
+			message, err := io.ReadAll(os.Stdin)
 			if err != nil {
-				fmt.Printf("Could not select a file: %v", err)
+				fmt.Printf("Problem trying to read message: %v", err)
 				return
 			}
-			m, err := tea.NewProgram(mod).StartReturningModel()
+			opts.Message = string(message)
+		}
+
+		var selectedFile string
+		if len(args) == 0 {
+			choice, err := SelectNote("Select File to Add a Date Entry to", true)
 			if err != nil {
-				fmt.Printf("Problem trying to get selection: %v", err)
-				return
-			}
-			mod, ok := m.(model)
-			if !ok {
-				fmt.Println("Could not read selection")
+				fmt.Printf("Could not select a file: %v", err)
 				return
 			}
-			selectedFile = mod.choice.Path
+			selectedFile = choice.Path
 		} else {
 			selectedFile = args[0]
 		}
-		file, err := os.OpenFile(selectedFile, os.O_RDWR|os.O_APPEND, os.ModePerm)
+		now := time.Now()
+		existing, err := addTimestamp(selectedFile, now, opts)
 		if err != nil {
-			fmt.Printf("Could not open file: %v, %v", args[0], err)
+			fmt.Printf("Could not add timestamp to file: %v", err)
 			return
 		}
-		defer file.Close()
-
-		err = addTimestamp(file, selectedFile, time.Now())
-		if err != nil {
-			fmt.Printf("Could not add timestamp to file: %v", err)
+		if existing {
+			if len(strings.TrimSpace(opts.Message)) > 0 {
+				fmt.Printf("Added to the existing %v entry in %v", formatEntryStamp(now, opts.Format), selectedFile)
+			} else {
+				fmt.Printf("%v already has a %v entry", selectedFile, formatEntryStamp(now, opts.Format))
+			}
 			return
 		}
-		fmt.Printf("Added %v entry line to %v", time.Now().Format(JOURNAL_DATE_FORMAT), selectedFile)
+		fmt.Printf("Added %v entry line to %v", formatEntryStamp(now, opts.Format), selectedFile)
 
 	},
 }
 
+var (
+	newTemplate string
+	newTitle    string
+	newTags     []string
+	newBody     string
+)
+
 var newNoteCmd = &cobra.Command{
 	Use:     "new",
 	Aliases: []string{"n"},
 	Short:   "creates a new note at the given path/name",
+	Long: `creates a new note at the given path/name. if no path is given, it goes into an interactive mode
+to fill in the directory, file name, title and tags. --template starts the note from a template in
+` + METADATA_DIR + "/" + TEMPLATE_DIR + `. templates are written as .txt notes whatever the note's format, and
+can use {{.Title}}, {{.Date}}, {{.Now}}, {{.Author}}, {{.Path}} and {{prompt "question"}} to ask for a
+value when the note is created. --title, --tag and --body fill in the rest of the note, --body - reads
+the body from stdin`,
+	Example: `notes new [--template meeting] [directory/file]
+notes new work/q3 --title "Q3 planning" --tag planning --tag q3
+some-command | notes new inbox/output --body -`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 1 {
-			return fmt.Errorf("got an unexpected number of args (%v), expected %v", len(args), 1)
+		if len(args) > 1 {
+			return fmt.Errorf("got an unexpected number of args (%v), expected at most %v", len(args), 1)
+		}
+		if len(args) == 0 {
+			if newBody == "-" {
+				return fmt.Errorf("a path is needed when reading the body from stdin")
+			}
+			return nil
 		}
-		// TODO: if no filename is provided go into an interactive mode
 		preparedFileName, err := checkExistance(args[0], false)
 		if err != nil {
 			return err
@@ -384,8 +646,39 @@ var newNoteCmd = &cobra.Command{
 		return nil
 	},
 	Run: func(_ *cobra.Command, args []string) {
-		if err := NewNoteFile(args[0]); err != nil {
-			fmt.Printf("Problem trying to cat: %v", err)
+		opts := NewNoteOptions{}
+		if len(args) == 0 {
+			notePath, formOpts, err := RunNewNoteForm()
+			if err != nil {
+				fmt.Printf("Could not create a note: %v", err)
+				return
+			}
+			preparedFileName, err := checkExistance(notePath, false)
+			if err != nil {
+				fmt.Printf("Could not create a note: %v", err)
+				return
+			}
+			args = []string{preparedFileName}
+			opts = formOpts
+		}
+		opts.Template = newTemplate
+		if len(newTitle) > 0 {
+			opts.Title = newTitle
+		}
+		for _, tag := range newTags {
+			opts.Tags = append(opts.Tags, splitTags(tag)...)
+		}
+		opts.Body = newBody
+		if newBody == "-" {
+			body, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Printf("Problem trying to read body: %v", err)
+				return
+			}
+			opts.Body = string(body)
+		}
+		if err := NewNoteFile(args[0], opts); err != nil {
+			fmt.Printf("Problem trying to create note: %v", err)
 		}
 	},
 }
@@ -400,16 +693,65 @@ var versionCmd = &cobra.Command{
 var rootCmd = &cobra.Command{
 	Use:   "notes",
 	Short: "Notes is a cli toolbox for plain text notes",
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if configErr != nil && isConfigCommand(cmd) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", configErr)
+		} else {
+			requireConfig()
+		}
+		return validateOutputFormat()
+	},
+	PersistentPostRun: func(_ *cobra.Command, _ []string) {
+		printSkippedFiles()
+	},
 	Long: `A cli toolbox for creating and managing plain text notes. 
-	all files are .txt so you do not need to specify .txt in the cli`,
+	notes are .txt (or the configured extension) files with a header, or .md files with yaml front matter.
+	the extension can be left off in the cli, new notes get the configured extension.
+	paths are relative to the notebook root, which is the current directory unless
+	--root, $NOTES_DIR or root in ~/.config/notes/config.toml say otherwise.
+	text files without a valid header are read as untitled notes named after the file, unless --strict is given`,
 }
 
 func init() {
+	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().StringVar(&rootFlag, "root", "", "directory of the notebook (defaults to $"+ROOT_ENV+", the config file, then the current directory)")
+	rootCmd.PersistentFlags().BoolVar(&strictParse, "strict", false, "skip files without a valid header instead of reading them as untitled notes")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", OUTPUT_TEXT, "output format: "+OUTPUT_TEXT+", "+OUTPUT_JSON+" or "+OUTPUT_NDJSON)
+	checkTagsCmd.Flags().BoolVar(&taggedContent, "content", false, "include the content of each note in json output")
+	newEntryCmd.Flags().BoolVar(&entryTime, "time", false, "stamp the entry with the time as well as the date")
+	newEntryCmd.Flags().StringVarP(&entryFormat, "format", "f", "", "date, datetime, week or a go time layout, defaults to the entry_format setting")
+	newEntryCmd.Flags().StringVar(&entryHeading, "heading", "", "heading written after the entry's stamp")
+	newEntryCmd.Flags().StringVarP(&entryMessage, "message", "m", "", "text of the entry, - reads it from stdin")
+	newEntryCmd.Flags().StringVar(&entryOrderFlag, "order", "", "prepend or append the entry, overriding the note's "+ENTRY_ORDER_FIELD+" field")
+	newEntryCmd.Flags().BoolVar(&entrySkipExisting, "skip-existing", false, "don't add a heading if the note already has an entry with the same stamp")
+	newNoteCmd.Flags().StringVarP(&newTemplate, "template", "t", "", "template to start the note from")
+	newNoteCmd.Flags().StringVar(&newTitle, "title", "", "title of the note, defaults to the file name")
+	newNoteCmd.Flags().StringArrayVar(&newTags, "tag", nil, "tag the note, can be repeated")
+	newNoteCmd.Flags().StringVar(&newBody, "body", "", "text to start the note with, - reads it from stdin")
+	checkTagsCmd.Flags().StringVar(&taggedMatch, "match", MATCH_FUZZY, "how terms match tags: "+MATCH_EXACT+", "+MATCH_PREFIX+" or "+MATCH_FUZZY)
+	// -term excludes a tag, so anything after the start of the query can't be a flag
+	checkTagsCmd.Flags().SetInterspersed(false)
+
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(checkTagsCmd)
 	rootCmd.AddCommand(catCmd)
 	rootCmd.AddCommand(newNoteCmd)
 	rootCmd.AddCommand(newEntryCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(indexCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(tagsCmd)
+	rootCmd.AddCommand(metaCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(todayCmd)
+	rootCmd.AddCommand(yesterdayCmd)
+	rootCmd.AddCommand(dayCmd)
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 }
 
 func Execute() {
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewNoteContents(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ROOT_ENV, root)
+	if err := os.MkdirAll(templatesDir(root), 0770); err != nil {
+		t.Fatalf("could not create templates directory: %v", err)
+	}
+	todo := "title: {{.Title}}\ntags: todo\n------\n- [ ] \n"
+	if err := os.WriteFile(filepath.Join(templatesDir(root), "todo.txt"), []byte(todo), 0660); err != nil {
+		t.Fatalf("could not write template: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		opts    NewNoteOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default header",
+			want: "title: q3\ntags:\n------\n",
+		},
+		{
+			name: "title tags and body",
+			opts: NewNoteOptions{Title: "Q3 planning", Tags: []string{"planning", "q3", "planning"}, Body: "first line"},
+			want: "title: Q3 planning\ntags: planning, q3\n------\nfirst line\n",
+		},
+		{
+			name: "template keeps its tags and body",
+			opts: NewNoteOptions{Template: "todo", Tags: []string{"q3"}, Body: "- [ ] plan\n"},
+			want: "title: q3\ntags: todo, q3\n------\n- [ ] \n- [ ] plan\n",
+		},
+		{
+			name: "markdown note from a template",
+			path: "q3.md",
+			opts: NewNoteOptions{Template: "todo", Tags: []string{"q3"}},
+			want: "---\ntitle: q3\ntags: [todo, q3]\n---\n- [ ] \n",
+		},
+		{
+			name:    "multi line title",
+			opts:    NewNoteOptions{Title: "Q3\nplanning"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		if len(tt.path) == 0 {
+			tt.path = "q3.txt"
+		}
+		got, err := newNoteContents(filepath.Join(root, "work", tt.path), tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v: contents mismatch:\nexpected: %q\ngot: %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+// runCommand runs notes with args, returning what it printed
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	read, write, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("could not capture output: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = write
+	rootCmd.SetArgs(args)
+	runErr := rootCmd.Execute()
+	os.Stdout = stdout
+	write.Close()
+	out, _ := io.ReadAll(read)
+	return string(out), runErr
+}
+
+func TestTaggedCommand(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(ROOT_ENV, root)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { taggedMatch = MATCH_FUZZY }()
+	notes := map[string]string{
+		"urgent.txt":   "title: urgent\ntags: work, urgent\n------\n",
+		"archived.txt": "title: archived\ntags: work, urgent, archived\n------\n",
+		"home.txt":     "title: home\ntags: home\n------\n",
+	}
+	for name, contents := range notes {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(contents), 0660); err != nil {
+			t.Fatalf("could not write test note: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "exclusion after the first term", args: []string{"tagged", "work", "+urgent", "-archived"}, want: []string{"urgent"}},
+		{name: "flags before the query", args: []string{"tagged", "--match", "exact", "work", "-archived"}, want: []string{"urgent"}},
+		{name: "leading exclusion after --", args: []string{"tagged", "--", "-archived"}, want: []string{"home", "urgent"}},
+		{name: "flag after the query", args: []string{"tagged", "work", "--match", "exact"}, wantErr: true},
+		{name: "shorthand flag after the query", args: []string{"tagged", "work", "-o", "json"}, wantErr: true},
+		{name: "shorthand flag before the query", args: []string{"tagged", "-o", "ndjson", "work", "-archived"}, want: []string{}},
+		{name: "tag named like a shorthand after --", args: []string{"tagged", "--match", "exact", "--", "-o"}, want: []string{"archived", "home", "urgent"}},
+	}
+	defer func() { outputFormat = OUTPUT_TEXT }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// flag values and where -- was are kept between parses, a real run only parses once.
+			// 0 is ContinueOnError, which cobra makes its flag sets with
+			checkTagsCmd.Flags().Init(checkTagsCmd.Name(), 0)
+			outputFormat = OUTPUT_TEXT
+			taggedMatch = MATCH_FUZZY
+			out, err := runCommand(t, tt.args...)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := make([]string, 0)
+			for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+				if title, _, ok := strings.Cut(line, " : "); ok {
+					got = append(got, title)
+				}
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("expected %v, got %v from %q", tt.want, got, out)
+			}
+		})
+	}
+}
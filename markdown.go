@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// markdown notes start with yaml front matter between two of these lines
+const FRONT_MATTER_DELIMITER = "---"
+
+var ErrMissingFrontMatter = errors.New("missing front matter")
+
+// markdownFormat is markdown with yaml front matter, where tags are a list:
+//
+//	---
+//	title: Q3 planning
+//	tags: [planning, q3]
+//	---
+type markdownFormat struct{}
+
+func (markdownFormat) Name() string {
+	return "md"
+}
+
+func (markdownFormat) Extensions() []string {
+	return []string{".md", ".markdown"}
+}
+
+// isFrontMatterEnd checks for the line closing front matter, yaml allows ... as well
+func isFrontMatterEnd(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == FRONT_MATTER_DELIMITER || line == "..."
+}
+
+func (m markdownFormat) Parse(reader io.Reader, path string, justHeader bool) (*Note, error) {
+	in := bufio.NewReader(reader)
+	first, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+	if strings.TrimSpace(strings.TrimPrefix(first, "\ufeff")) != FRONT_MATTER_DELIMITER {
+		return nil, ErrMissingFrontMatter
+	}
+
+	var frontMatter strings.Builder
+	closed := false
+	for !closed {
+		line, err := in.ReadString('\n')
+		if len(line) > 0 {
+			if isFrontMatterEnd(line) {
+				closed = true
+				break
+			}
+			frontMatter.WriteString(line)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read file: %w", err)
+		}
+	}
+	if !closed {
+		return nil, fmt.Errorf("front matter is missing its closing %v", FRONT_MATTER_DELIMITER)
+	}
+
+	note, err := parseFrontMatter(frontMatter.String(), path)
+	if err != nil {
+		return nil, err
+	}
+	// the opening line is kept so that the content's line numbers work out
+	note.rawHeader = FRONT_MATTER_DELIMITER + "\n" + frontMatter.String()
+	if !justHeader {
+		content, err := io.ReadAll(in)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file: %w", err)
+		}
+		note.Content = string(content)
+	}
+	return note, nil
+}
+
+// decodeFrontMatter parses front matter into its mapping of fields, front matter with nothing but comments
+// gets an empty mapping
+func decodeFrontMatter(text string) (*yaml.Node, *yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		return nil, nil, fmt.Errorf("could not parse front matter: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}, HeadComment: doc.HeadComment}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("front matter has to be a set of fields")
+	}
+	return &doc, mapping, nil
+}
+
+// yamlFieldValue flattens a front matter value into a single string the way the rest of notes expects them,
+// lists are joined with commas like txt tags are
+func yamlFieldValue(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return ""
+		}
+		return node.Value
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if value := yamlFieldValue(item); len(value) > 0 {
+				values = append(values, value)
+			}
+		}
+		return strings.Join(values, ", ")
+	case yaml.AliasNode:
+		return yamlFieldValue(node.Alias)
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// yamlValue is the node written for a field's value. tags are written as a list, everything else as text
+func yamlValue(field, value string) *yaml.Node {
+	if !strings.EqualFold(field, "tags") {
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, tag := range splitTags(value) {
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: tag})
+	}
+	return list
+}
+
+// replaceYAMLValue is yamlValue for a field that's already set, keeping how the old value was written
+func replaceYAMLValue(old *yaml.Node, field, value string) *yaml.Node {
+	node := yamlValue(field, value)
+	if old.Kind == node.Kind {
+		node.Style = old.Style
+	}
+	// a block list with nothing in it can only be written as []
+	if node.Kind == yaml.SequenceNode && len(node.Content) == 0 {
+		node.Style = yaml.FlowStyle
+	}
+	node.HeadComment = old.HeadComment
+	node.LineComment = old.LineComment
+	node.FootComment = old.FootComment
+	return node
+}
+
+func parseFrontMatter(text, path string) (*Note, error) {
+	_, mapping, err := decodeFrontMatter(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(mapping.Content) == 0 {
+		return nil, ErrEmptyHeader
+	}
+	note := &Note{Path: path}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		fieldValue := yamlFieldValue(value)
+		note.Fields = append(note.Fields, Field{Key: key.Value, Value: fieldValue})
+		switch strings.ToLower(key.Value) {
+		case "title":
+			note.Title = fieldValue
+		case "tags":
+			if tags := uniqueTags(splitTags(fieldValue)); len(tags) > 0 {
+				note.Tags = tags
+			}
+		}
+	}
+	return note, nil
+}
+
+func (markdownFormat) Split(data []byte) ([]byte, []byte, bool) {
+	rest := data
+	for lineNum := 0; len(rest) > 0; lineNum++ {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line = rest[:i+1]
+		}
+		rest = rest[len(line):]
+		if lineNum == 0 {
+			if strings.TrimSpace(strings.TrimPrefix(string(line), "\ufeff")) != FRONT_MATTER_DELIMITER {
+				return nil, nil, false
+			}
+			continue
+		}
+		if isFrontMatterEnd(string(line)) {
+			return data[:len(data)-len(rest)], rest, true
+		}
+	}
+	return nil, nil, false
+}
+
+// frontMatter returns the yaml between the delimiters of a note's raw contents
+func (m markdownFormat) frontMatter(data []byte) (string, []byte, error) {
+	header, content, ok := m.Split(data)
+	if !ok {
+		return "", nil, ErrMissingFrontMatter
+	}
+	lines := strings.SplitAfter(string(header), "\n")
+	// drop the opening line, the closing line and the empty string after it
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines[1:len(lines)-1], ""), content, nil
+}
+
+func encodeFrontMatter(doc *yaml.Node, content []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(FRONT_MATTER_DELIMITER + "\n")
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("could not write front matter: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("could not write front matter: %w", err)
+	}
+	b.WriteString(FRONT_MATTER_DELIMITER + "\n")
+	b.Write(content)
+	return b.Bytes(), nil
+}
+
+func (markdownFormat) Render(fields Fields, content string) ([]byte, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range fields {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Key}, yamlValue(field.Key, field.Value))
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{mapping}}
+	return encodeFrontMatter(doc, []byte(content))
+}
+
+// fieldIndexes finds where field's key is in the mapping, matching case like Fields.Get does
+func fieldIndexes(mapping *yaml.Node, field string) []int {
+	results := make([]int, 0)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, field) {
+			results = append(results, i)
+		}
+	}
+	return results
+}
+
+func (m markdownFormat) SetField(data []byte, field, value string) ([]byte, error) {
+	if err := validateHeaderField(field, value); err != nil {
+		return nil, err
+	}
+	text, content, err := m.frontMatter(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, mapping, err := decodeFrontMatter(text)
+	if err != nil {
+		return nil, err
+	}
+	indexes := fieldIndexes(mapping, field)
+	if len(indexes) == 0 {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field}, yamlValue(field, value))
+	}
+	for _, i := range indexes {
+		mapping.Content[i+1] = replaceYAMLValue(mapping.Content[i+1], field, value)
+	}
+	return encodeFrontMatter(doc, content)
+}
+
+func (m markdownFormat) RemoveField(data []byte, field string) ([]byte, bool) {
+	text, content, err := m.frontMatter(data)
+	if err != nil {
+		return data, false
+	}
+	doc, mapping, err := decodeFrontMatter(text)
+	if err != nil {
+		return data, false
+	}
+	indexes := fieldIndexes(mapping, field)
+	if len(indexes) == 0 {
+		return data, false
+	}
+	for i := len(indexes) - 1; i >= 0; i-- {
+		mapping.Content = append(mapping.Content[:indexes[i]], mapping.Content[indexes[i]+2:]...)
+	}
+	out, err := encodeFrontMatter(doc, content)
+	if err != nil {
+		return data, false
+	}
+	return out, true
+}
+
+func (m markdownFormat) RewriteField(data []byte, field string, update func(value string) string) ([]byte, bool) {
+	text, content, err := m.frontMatter(data)
+	if err != nil {
+		return data, false
+	}
+	doc, mapping, err := decodeFrontMatter(text)
+	if err != nil {
+		return data, false
+	}
+	changed := false
+	for _, i := range fieldIndexes(mapping, field) {
+		current := yamlFieldValue(mapping.Content[i+1])
+		if newValue := update(current); newValue != current {
+			mapping.Content[i+1] = replaceYAMLValue(mapping.Content[i+1], field, newValue)
+			changed = true
+		}
+	}
+	if !changed {
+		return data, false
+	}
+	out, err := encodeFrontMatter(doc, content)
+	if err != nil {
+		return data, false
+	}
+	return out, true
+}
+
+// hasYAMLComments checks a node and everything under it for comments
+func hasYAMLComments(node *yaml.Node) bool {
+	if len(node.HeadComment) > 0 || len(node.LineComment) > 0 || len(node.FootComment) > 0 {
+		return true
+	}
+	for _, child := range node.Content {
+		if hasYAMLComments(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// frontMatterLosses describes what of a markdown note's front matter can't be kept as plain key: value fields
+func frontMatterLosses(rawHeader string) []string {
+	lost := make([]string, 0)
+	doc, mapping, err := decodeFrontMatter(strings.TrimPrefix(rawHeader, FRONT_MATTER_DELIMITER+"\n"))
+	if err != nil {
+		return lost
+	}
+	if hasYAMLComments(doc) {
+		lost = append(lost, "comments in the front matter are dropped")
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i].Value, mapping.Content[i+1]
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+		switch {
+		case value.Kind == yaml.SequenceNode && !strings.EqualFold(key, "tags"):
+			lost = append(lost, fmt.Sprintf("list `%v` becomes comma separated text", key))
+		case value.Kind == yaml.MappingNode:
+			lost = append(lost, fmt.Sprintf("`%v` has nested fields, they become yaml text", key))
+		}
+	}
+	return lost
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		contents    string
+		want        *Note
+		wantedError bool
+	}{
+		{
+			name:     "tags as a list",
+			contents: "---\ntitle: Q3 planning\ntags: [planning, q3]\nowner: 'Sam: lead'\n---\n# Q3\n",
+			want: &Note{
+				Path:      "plan.md",
+				Title:     "Q3 planning",
+				Tags:      []string{"planning", "q3"},
+				Content:   "# Q3\n",
+				Fields:    Fields{{Key: "title", Value: "Q3 planning"}, {Key: "tags", Value: "planning, q3"}, {Key: "owner", Value: "Sam: lead"}},
+				rawHeader: "---\ntitle: Q3 planning\ntags: [planning, q3]\nowner: 'Sam: lead'\n",
+			},
+		},
+		{
+			name:     "block list and comments",
+			contents: "---\n# notes about the note\ntags:\n  - a\n  - b\n...\nbody",
+			want: &Note{
+				Path:      "plan.md",
+				Tags:      []string{"a", "b"},
+				Content:   "body",
+				Fields:    Fields{{Key: "tags", Value: "a, b"}},
+				rawHeader: "---\n# notes about the note\ntags:\n  - a\n  - b\n",
+			},
+		},
+		{
+			name:        "no front matter",
+			contents:    "# just markdown\n",
+			wantedError: true,
+		},
+		{
+			name:        "front matter never closed",
+			contents:    "---\ntitle: open\n",
+			wantedError: true,
+		},
+		{
+			name:        "front matter that isn't fields",
+			contents:    "---\n- a\n- b\n---\n",
+			wantedError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := markdownFormat{}.Parse(strings.NewReader(tt.contents), "plan.md", false)
+			if tt.wantedError {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("note mismatch:\nexpected: %+v\ngot: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMarkdownFieldEdits(t *testing.T) {
+	md := markdownFormat{}
+	note := []byte("---\ntitle: a\n# who's on it\nStatus: open\ntags:\n  - x\n  - y\n---\nstatus: body\n")
+
+	set, err := md.SetField(note, "status", "closed: for now")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "---\ntitle: a\n# who's on it\nStatus: 'closed: for now'\ntags:\n  - x\n  - y\n---\nstatus: body\n"; string(set) != want {
+		t.Errorf("set existing mismatch:\nexpected: %q\ngot: %q", want, string(set))
+	}
+
+	added, err := md.SetField(note, "due", "2026-11-01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "---\ntitle: a\n# who's on it\nStatus: open\ntags:\n  - x\n  - y\ndue: 2026-11-01\n---\nstatus: body\n"; string(added) != want {
+		t.Errorf("set new mismatch:\nexpected: %q\ngot: %q", want, string(added))
+	}
+
+	removed, ok := md.RemoveField(note, "STATUS")
+	if !ok {
+		t.Fatalf("expected field to be removed")
+	}
+	if want := "---\ntitle: a\ntags:\n  - x\n  - y\n---\nstatus: body\n"; string(removed) != want {
+		t.Errorf("unset mismatch:\nexpected: %q\ngot: %q", want, string(removed))
+	}
+
+	retagged, ok := md.RewriteField(note, "tags", func(value string) string {
+		return strings.Replace(value, "x", "z", 1)
+	})
+	if !ok {
+		t.Fatalf("expected tags to be rewritten")
+	}
+	if want := "---\ntitle: a\n# who's on it\nStatus: open\ntags:\n  - z\n  - y\n---\nstatus: body\n"; string(retagged) != want {
+		t.Errorf("rewrite mismatch:\nexpected: %q\ngot: %q", want, string(retagged))
+	}
+
+	if _, err := md.SetField([]byte("# no front matter\n"), "status", "open"); !errors.Is(err, ErrMissingFrontMatter) {
+		t.Errorf("expected missing front matter error, got %v", err)
+	}
+	if _, err := md.SetField(note, "key", "multi\nline"); err == nil {
+		t.Errorf("expected error setting a multi line value")
+	}
+}
+
+func TestMarkdownRender(t *testing.T) {
+	md := markdownFormat{}
+	fields := Fields{{Key: "title", Value: "Q3: planning"}, {Key: "tags", Value: "planning, q3"}}
+	got, err := md.Render(fields, "body\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "---\ntitle: 'Q3: planning'\ntags: [planning, q3]\n---\nbody\n"; string(got) != want {
+		t.Errorf("render mismatch:\nexpected: %q\ngot: %q", want, string(got))
+	}
+
+	header, content, ok := md.Split(got)
+	if !ok || string(header) != "---\ntitle: 'Q3: planning'\ntags: [planning, q3]\n---\n" || string(content) != "body\n" {
+		t.Errorf("split mismatch: %q %q %v", header, content, ok)
+	}
+
+	note, err := md.Parse(strings.NewReader(string(got)), "plan.md", false)
+	if err != nil {
+		t.Fatalf("could not parse rendered note: %v", err)
+	}
+	if !reflect.DeepEqual(fields, note.Fields) || note.Content != "body\n" || note.contentLine() != 4 {
+		t.Errorf("round trip mismatch: %+v", note)
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	tests := map[string]string{
+		"plan.md":       "md",
+		"Plan.MARKDOWN": "md",
+		"plan.txt":      "txt",
+		"plan":          "txt",
+	}
+	for path, want := range tests {
+		if got := formatFor(path).Name(); got != want {
+			t.Errorf("%v: expected %v, got %v", path, want, got)
+		}
+	}
+	if got := noteName("dir/plan.markdown"); got != "plan" {
+		t.Errorf("expected note name plan, got %v", got)
+	}
+
+	// markdown without front matter is still a note, the same as headerless txt files
+	dir := t.TempDir()
+	path := filepath.Join(dir, "readme.md")
+	if err := os.WriteFile(path, []byte("# readme\n"), 0660); err != nil {
+		t.Fatalf("could not write test file: %v", err)
+	}
+	note, err := parseNoteFile(path, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note.Title != "readme" || note.Content != "# readme\n" || !errors.Is(note.headerErr, ErrMissingFrontMatter) {
+		t.Errorf("expected an untitled note, got %+v", note)
+	}
+}
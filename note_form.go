@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	formDirectory = iota
+	formName
+	formTitle
+	formTags
+)
+
+// how many completions get shown under the focused input
+const MAX_SUGGESTIONS = 5
+
+var (
+	labelStyle      = lipgloss.NewStyle().Width(11)
+	suggestionStyle = lipgloss.NewStyle().PaddingLeft(13).Foreground(lipgloss.Color("240"))
+	formHelpStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	formErrorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("160"))
+)
+
+// newNoteForm asks for everything needed to create a note when new is run without a file name
+type newNoteForm struct {
+	inputs      []textinput.Model
+	focus       int
+	directories []string
+	tags        []string
+	submitted   bool
+	problem     string
+}
+
+// noteDirectories lists every directory in the notebook that has notes in it, relative to root
+func noteDirectories(root string, fileList []string) []string {
+	seen := make(map[string]bool)
+	dirs := make([]string, 0)
+	for _, fileName := range fileList {
+		dir := filepath.Dir(indexKey(root, fileName))
+		for dir != "." && dir != "/" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+			dir = filepath.Dir(dir)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// completeDirectory suggests directories that start with what's been typed so far
+func completeDirectory(directories []string, input string) []string {
+	results := make([]string, 0)
+	for _, dir := range directories {
+		if dir != input && strings.HasPrefix(strings.ToLower(dir), strings.ToLower(input)) {
+			results = append(results, dir)
+		}
+		if len(results) == MAX_SUGGESTIONS {
+			break
+		}
+	}
+	return results
+}
+
+// completeTags suggests tags for the last tag being typed in a comma separated list, leaving out tags already listed
+func completeTags(tags []string, input string) []string {
+	parts := strings.Split(input, ",")
+	current := strings.TrimSpace(parts[len(parts)-1])
+	if len(current) == 0 {
+		return []string{}
+	}
+	results := make([]string, 0)
+	for _, tag := range tags {
+		if strings.EqualFold(tag, current) || hasAnyTag(splitTags(strings.Join(parts[:len(parts)-1], ",")), []string{tag}) {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(tag), strings.ToLower(current)) {
+			results = append(results, tag)
+		}
+		if len(results) == MAX_SUGGESTIONS {
+			break
+		}
+	}
+	return results
+}
+
+// splitTags turns a comma separated list of tags into its tags, dropping empty ones
+func splitTags(input string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(input, ",") {
+		if tag = strings.TrimSpace(tag); len(tag) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func NewNoteForm(directories, tags []string) newNoteForm {
+	labels := []string{"Directory", "File name", "Title", "Tags"}
+	placeholders := []string{"(notebook root)", "required", "defaults to the file name", "comma separated"}
+	inputs := make([]textinput.Model, len(labels))
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = labelStyle.Render(labels[i]) + "> "
+		inputs[i].Placeholder = placeholders[i]
+	}
+	inputs[formDirectory].Focus()
+	return newNoteForm{
+		inputs:      inputs,
+		directories: directories,
+		tags:        tags,
+	}
+}
+
+func (f newNoteForm) suggestions() []string {
+	switch f.focus {
+	case formDirectory:
+		return completeDirectory(f.directories, f.inputs[formDirectory].Value())
+	case formTags:
+		return completeTags(f.tags, f.inputs[formTags].Value())
+	}
+	return []string{}
+}
+
+func (f *newNoteForm) complete(suggestion string) {
+	input := &f.inputs[f.focus]
+	if f.focus == formTags {
+		tags := splitTags(input.Value())
+		tags[len(tags)-1] = suggestion
+		suggestion = strings.Join(tags, ", ") + ", "
+	}
+	input.SetValue(suggestion)
+	input.CursorEnd()
+}
+
+func (f *newNoteForm) setFocus(i int) tea.Cmd {
+	if i < 0 || i >= len(f.inputs) {
+		return nil
+	}
+	f.inputs[f.focus].Blur()
+	f.focus = i
+	return f.inputs[f.focus].Focus()
+}
+
+func (f newNoteForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f newNoteForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		f.problem = ""
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return f, tea.Quit
+		case "tab":
+			if suggestions := f.suggestions(); len(suggestions) > 0 {
+				f.complete(suggestions[0])
+				return f, nil
+			}
+			return f, f.setFocus(f.focus + 1)
+		case "shift+tab", "up":
+			return f, f.setFocus(f.focus - 1)
+		case "down":
+			return f, f.setFocus(f.focus + 1)
+		case "enter":
+			if f.focus < len(f.inputs)-1 {
+				return f, f.setFocus(f.focus + 1)
+			}
+			if len(strings.TrimSpace(f.inputs[formName].Value())) == 0 {
+				f.problem = "a file name is required"
+				return f, f.setFocus(formName)
+			}
+			if _, err := cleanNotebookPath(f.path()); err != nil {
+				f.problem = err.Error()
+				return f, f.setFocus(formDirectory)
+			}
+			f.submitted = true
+			return f, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return f, cmd
+}
+
+func (f newNoteForm) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("New Note") + "\n\n")
+	for i, input := range f.inputs {
+		b.WriteString(input.View() + "\n")
+		if i == f.focus {
+			for _, suggestion := range f.suggestions() {
+				b.WriteString(suggestionStyle.Render(suggestion) + "\n")
+			}
+		}
+	}
+	if len(f.problem) > 0 {
+		b.WriteString("\n" + formErrorStyle.Render(f.problem) + "\n")
+	}
+	b.WriteString("\n" + formHelpStyle.Render("tab: complete/next • shift+tab: previous • enter: next/create • esc: cancel"))
+	return appStyle.Render(b.String())
+}
+
+// path is where the note should go, relative to the notebook
+func (f newNoteForm) path() string {
+	return filepath.Join(strings.TrimSpace(f.inputs[formDirectory].Value()), strings.TrimSpace(f.inputs[formName].Value()))
+}
+
+// RunNewNoteForm asks for the details of a new note, returning its path relative to the notebook and how to fill in its header
+func RunNewNoteForm() (string, NewNoteOptions, error) {
+	root, err := notesRoot()
+	if err != nil {
+		return "", NewNoteOptions{}, err
+	}
+	notes, err := collectFiles(true)
+	if err != nil {
+		return "", NewNoteOptions{}, fmt.Errorf("problem getting files: %w", err)
+	}
+	inventory, err := TagInventory(notes, SORT_COUNT)
+	if err != nil {
+		return "", NewNoteOptions{}, err
+	}
+	tags := make([]string, len(inventory))
+	for i, tag := range inventory {
+		tags[i] = tag.Tag
+	}
+
+	m, err := tea.NewProgram(NewNoteForm(noteDirectories(root, files(root)), tags)).StartReturningModel()
+	if err != nil {
+		return "", NewNoteOptions{}, fmt.Errorf("problem running form: %w", err)
+	}
+	form, ok := m.(newNoteForm)
+	if !ok {
+		return "", NewNoteOptions{}, fmt.Errorf("could not read form")
+	}
+	if !form.submitted {
+		return "", NewNoteOptions{}, fmt.Errorf("cancelled")
+	}
+	return form.path(), NewNoteOptions{
+		Title: strings.TrimSpace(form.inputs[formTitle].Value()),
+		Tags:  splitTags(form.inputs[formTags].Value()),
+	}, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNoteDirectories(t *testing.T) {
+	root := filepath.FromSlash("/notes")
+	fileList := []string{
+		filepath.FromSlash("/notes/top.txt"),
+		filepath.FromSlash("/notes/work/meetings/standup.txt"),
+		filepath.FromSlash("/notes/journal/2026.txt"),
+	}
+	want := []string{"journal", "work", filepath.FromSlash("work/meetings")}
+	if got := noteDirectories(root, fileList); !reflect.DeepEqual(want, got) {
+		t.Errorf("directories mismatch:\nexpected: %v\ngot: %v", want, got)
+	}
+}
+
+func TestCompleteTags(t *testing.T) {
+	tags := []string{"work", "workshop", "home", "Writing"}
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{input: "", want: []string{}},
+		{input: "wo", want: []string{"work", "workshop"}},
+		{input: "home, w", want: []string{"work", "workshop", "Writing"}},
+		{input: "work, wo", want: []string{"workshop"}},
+		{input: "work,", want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := completeTags(tags, tt.input); !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("completion mismatch for %q:\nexpected: %v\ngot: %v", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestNewNoteForm(t *testing.T) {
+	var m tea.Model = NewNoteForm([]string{"journal", "work", "work/meetings"}, []string{"planning", "q3"})
+	send := func(msgs ...tea.Msg) {
+		for _, msg := range msgs {
+			m, _ = m.Update(msg)
+		}
+	}
+	typed := func(s string) tea.Msg {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	// complete the directory, then move on
+	send(typed("wo"), tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	// submitting without a file name isn't allowed
+	send(tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter})
+	form := m.(newNoteForm)
+	if form.submitted || form.focus != formName {
+		t.Fatalf("expected form to go back to the file name, focus is %v", form.focus)
+	}
+
+	send(typed("q3-planning"), tea.KeyMsg{Type: tea.KeyEnter}, typed("Q3 planning"), tea.KeyMsg{Type: tea.KeyEnter})
+	send(typed("pl"), tea.KeyMsg{Type: tea.KeyTab}, typed("q"), tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	form = m.(newNoteForm)
+	if !form.submitted {
+		t.Fatalf("expected form to be submitted")
+	}
+	if got := form.path(); got != filepath.Join("work", "q3-planning") {
+		t.Errorf("path mismatch: %v", got)
+	}
+	if got := form.inputs[formTitle].Value(); got != "Q3 planning" {
+		t.Errorf("title mismatch: %v", got)
+	}
+	if got := splitTags(form.inputs[formTags].Value()); !reflect.DeepEqual(got, []string{"planning", "q3"}) {
+		t.Errorf("tags mismatch: %v", got)
+	}
+
+	// notes can't be made outside of the notebook
+	for _, bad := range [][2]string{{"..", "x"}, {"", "../x"}, {"work", "../../x"}, {"", ".."}, {"/tmp", "x"}, {".notes", "x"}} {
+		m = NewNoteForm(nil, nil)
+		send(typed(bad[0]), tea.KeyMsg{Type: tea.KeyEnter}, typed(bad[1]))
+		send(tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyEnter})
+		form = m.(newNoteForm)
+		if form.submitted || len(form.problem) == 0 || form.focus != formDirectory {
+			t.Errorf("expected %q to be rejected, got problem %q", filepath.Join(bad[0], bad[1]), form.problem)
+		}
+	}
+}
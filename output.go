@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	OUTPUT_TEXT   = "text"
+	OUTPUT_JSON   = "json"
+	OUTPUT_NDJSON = "ndjson"
+)
+
+// set by the --output flag
+var outputFormat = OUTPUT_TEXT
+
+func validateOutputFormat() error {
+	switch outputFormat {
+	case OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_NDJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown output format `%v`, expected one of %v, %v or %v", outputFormat, OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_NDJSON)
+}
+
+// NoteRecord is how a note looks in json output
+type NoteRecord struct {
+	Path    string   `json:"path"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags"`
+	Fields  Fields   `json:"fields"`
+	Content *string  `json:"content,omitempty"`
+}
+
+func NewNoteRecord(note Note, withContent bool) NoteRecord {
+	record := NoteRecord{
+		Path:   note.Path,
+		Title:  note.Title,
+		Tags:   note.Tags,
+		Fields: note.Fields,
+	}
+	if record.Tags == nil {
+		record.Tags = []string{}
+	}
+	if withContent {
+		content := note.Content
+		record.Content = &content
+	}
+	return record
+}
+
+func noteRecords(notes []Note, withContent bool) []NoteRecord {
+	records := make([]NoteRecord, len(notes))
+	for i, note := range notes {
+		records[i] = NewNoteRecord(note, withContent)
+	}
+	return records
+}
+
+// encodeRecords writes records as a json array, or as one json object per line for ndjson
+func encodeRecords[T any](w io.Writer, records []T, ndjson bool) error {
+	encoder := json.NewEncoder(w)
+	if !ndjson {
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeOutput prints records in the format picked with --output, text output is left up to the caller
+func writeOutput[T any](records []T, text func(record T)) error {
+	switch outputFormat {
+	case OUTPUT_JSON, OUTPUT_NDJSON:
+		return encodeRecords(os.Stdout, records, outputFormat == OUTPUT_NDJSON)
+	}
+	for _, record := range records {
+		text(record)
+	}
+	return nil
+}
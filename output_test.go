@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeRecords(t *testing.T) {
+	content := "body\n"
+	notes := []Note{
+		{
+			Path:    "work/plan.txt",
+			Title:   "Plan",
+			Tags:    []string{"work", "q3"},
+			Fields:  Fields{{Key: "title", Value: "Plan"}, {Key: "Zeta", Value: "last"}, {Key: "alpha", Value: "first"}, {Key: "zeta", Value: "repeated"}},
+			Content: content,
+		},
+		{Path: "empty.txt", Title: "empty"},
+	}
+
+	tests := []struct {
+		name        string
+		ndjson      bool
+		withContent bool
+		want        string
+	}{
+		{
+			name:   "json array",
+			ndjson: false,
+			want: `[
+  {
+    "path": "work/plan.txt",
+    "title": "Plan",
+    "tags": [
+      "work",
+      "q3"
+    ],
+    "fields": {
+      "title": "Plan",
+      "alpha": "first",
+      "zeta": "repeated"
+    }
+  },
+  {
+    "path": "empty.txt",
+    "title": "empty",
+    "tags": [],
+    "fields": {}
+  }
+]
+`,
+		},
+		{
+			name:        "ndjson with content",
+			ndjson:      true,
+			withContent: true,
+			want: `{"path":"work/plan.txt","title":"Plan","tags":["work","q3"],"fields":{"title":"Plan","alpha":"first","zeta":"repeated"},"content":"body\n"}
+{"path":"empty.txt","title":"empty","tags":[],"fields":{},"content":""}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := encodeRecords(&out, noteRecords(notes, tt.withContent), tt.ndjson); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output mismatch:\nexpected: %v\ngot: %v", tt.want, got)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	if err := encodeRecords(&out, []NoteRecord{}, false); err != nil || out.String() != "[]\n" {
+		t.Errorf("expected an empty array for no notes, got %q %v", out.String(), err)
+	}
+	out.Reset()
+	if err := encodeRecords(&out, []NoteRecord{}, true); err != nil || out.Len() != 0 {
+		t.Errorf("expected no lines for no notes, got %q %v", out.String(), err)
+	}
+}
@@ -2,26 +2,30 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"strings"
+	"unicode/utf8"
 )
 
 var ErrEmptyHeader = errors.New("empty header")
 
 type ErrInvalidHeader struct {
-	line string
+	line    string
+	lineNum int
 }
 
 func (e ErrInvalidHeader) Error() string {
-	return fmt.Sprintf("could not parse header line: %v", e.line)
+	return fmt.Sprintf("could not parse header line %v: %v", e.lineNum, e.line)
 }
 
-//TODO: make parser a bit more robust, in particular we want it to be able to gracefully handle non-note text files
 func ParseNote(reader io.Reader, path string, justHeader bool) (*Note, error) {
 	in := bufio.NewReader(reader)
 	var curLine string
+	lineNum := 0
 	done := false
 	isHeader := true
 	buf := make([]byte, 2000)
@@ -39,7 +43,8 @@ func ParseNote(reader io.Reader, path string, justHeader bool) (*Note, error) {
 			if prefix {
 				continue
 			}
-			if strings.TrimSpace(curLine) == DIVIDER {
+			lineNum++
+			if strings.TrimSpace(curLine) == settings.Divider {
 				isHeader = false
 				curLine = ""
 				if len(result.rawHeader) == 0 {
@@ -50,10 +55,11 @@ func ParseNote(reader io.Reader, path string, justHeader bool) (*Note, error) {
 			result.rawHeader += fmt.Sprintf("%v\n", curLine)
 			headerData := strings.Split(curLine, ":")
 			if len(headerData) < 2 {
-				return nil, ErrInvalidHeader{line: curLine}
+				return nil, ErrInvalidHeader{line: curLine, lineNum: lineNum}
 			}
 			field := headerData[0]
 			value := strings.Join(headerData[1:], ":")
+			result.Fields = append(result.Fields, Field{Key: strings.TrimSpace(field), Value: strings.TrimSpace(value)})
 			switch strings.TrimSpace(strings.ToLower(field)) {
 			case "title":
 				result.Title = strings.TrimSpace(value)
@@ -102,3 +108,83 @@ func ParseNote(reader io.Reader, path string, justHeader bool) (*Note, error) {
 	}
 	return result, nil
 }
+
+// how much of a file gets looked at to decide whether it's text
+const TEXT_SNIFF_SIZE = 8000
+
+// set by --strict, files without a valid header are skipped rather than read as untitled notes
+var strictParse bool
+
+// SkippedFile is a file that was left out when collecting notes, and why
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// looksLikeText guesses whether data is text, binary files tend to have NUL bytes or invalid utf-8 early on
+func looksLikeText(data []byte) bool {
+	if len(data) > TEXT_SNIFF_SIZE {
+		data = data[:TEXT_SNIFF_SIZE]
+		// the cut may have landed in the middle of a character
+		for i := 0; i < utf8.UTFMax && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.IndexByte(data, 0) < 0 && utf8.Valid(data)
+}
+
+// parseNoteFile reads the note at filePath. a text file without a valid header is still read, as an untitled
+// note named after the file with the whole file as its content and the problem with its header in headerErr
+func parseNoteFile(filePath string, justHeader bool) (*Note, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	defer f.Close()
+	note, headerErr := parseNote(f, filePath, justHeader)
+	if headerErr == nil {
+		return note, nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+	var data []byte
+	if justHeader {
+		data, err = io.ReadAll(io.LimitReader(f, TEXT_SNIFF_SIZE))
+	} else {
+		data, err = io.ReadAll(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+	if !looksLikeText(data) {
+		return nil, fmt.Errorf("not a text file")
+	}
+	note = &Note{
+		Path:      filePath,
+		Title:     noteName(filePath),
+		headerErr: headerErr,
+	}
+	if !justHeader {
+		note.Content = string(data)
+	}
+	return note, nil
+}
+
+// strictNotes leaves out the notes that were read without a valid header when parsing strictly
+func strictNotes(notes []Note) ([]Note, []SkippedFile) {
+	if !strictParse {
+		return notes, nil
+	}
+	results := make([]Note, 0, len(notes))
+	skipped := make([]SkippedFile, 0)
+	for _, note := range notes {
+		if note.headerErr != nil {
+			skipped = append(skipped, SkippedFile{Path: note.Path, Reason: note.headerErr.Error()})
+			continue
+		}
+		results = append(results, note)
+	}
+	return results, skipped
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -109,7 +110,7 @@ tags: ipsum
 			name:        "invalid header field",
 			fileName:    "bad_header_02.txt",
 			headerOnly:  false,
-			wantedError: ErrInvalidHeader{line: "boop"},
+			wantedError: ErrInvalidHeader{line: "boop", lineNum: 2},
 			want:        nil,
 		},
 	}
@@ -190,3 +191,43 @@ func parseNoteConbini(name string, headerOnly bool) (*Note, error) {
 
 	return ParseNote(file, path, headerOnly)
 }
+
+func TestParseNoteFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0660); err != nil {
+			t.Fatalf("could not write test file: %v", err)
+		}
+		return path
+	}
+	readme := write("README.txt", "# notes\nsome: text\nthat isn't a note\n")
+	binary := write("image.txt", "\x89PNG\r\n\x1a\n\x00\x00")
+	valid := write("valid.txt", "title: valid\n------\nbody\n")
+
+	note, err := parseNoteFile(readme, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note.Title != "README" || note.Content != "# notes\nsome: text\nthat isn't a note\n" || note.contentLine() != 0 {
+		t.Errorf("expected the whole file as an untitled note, got %+v", note)
+	}
+	if !errors.As(note.headerErr, &ErrInvalidHeader{}) {
+		t.Errorf("expected the header error to be kept, got %v", note.headerErr)
+	}
+	if _, err := parseNoteFile(binary, true); err == nil {
+		t.Errorf("expected binary file to be rejected")
+	}
+	if note, err := parseNoteFile(valid, false); err != nil || note.headerErr != nil || note.contentLine() != 2 {
+		t.Errorf("expected a valid note to parse normally, got %+v %v", note, err)
+	}
+
+	strictParse = true
+	defer func() { strictParse = false }()
+	if _, err := readNote(readme, true); err == nil {
+		t.Errorf("expected strict parsing to reject a file without a header")
+	}
+	if _, err := readNote(valid, true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/spf13/cobra"
+)
+
+// how much of a matching line gets shown around the first match
+const SNIPPET_WIDTH = 80
+
+var matchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#25A065"))
+
+// highlightMatches is whether stdout shows colours, so that piped output isn't full of escape codes
+var highlightMatches = func() bool {
+	return lipgloss.ColorProfile() != termenv.Ascii
+}
+
+type SearchMatch struct {
+	Path string `json:"path"`
+	// line number within the file, not the content
+	Line int    `json:"line"`
+	Text string `json:"text"`
+	// byte offsets of every match within Text
+	Spans [][]int `json:"spans"`
+}
+
+func NewSearchMatcher(query string, ignoreCase, useRegex bool) (*regexp.Regexp, error) {
+	if len(query) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+	if !useRegex {
+		query = regexp.QuoteMeta(query)
+	}
+	if ignoreCase {
+		query = "(?i)" + query
+	}
+	matcher, err := regexp.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+	return matcher, nil
+}
+
+// SearchNotes looks through the contents of each note line by line. notes need to have been parsed with their content
+func SearchNotes(notes []Note, matcher *regexp.Regexp) []SearchMatch {
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Path < notes[j].Path })
+
+	results := make([]SearchMatch, 0)
+	for _, note := range notes {
+		offset := note.contentLine()
+		for i, line := range strings.Split(note.Content, "\n") {
+			spans := matcher.FindAllStringIndex(line, -1)
+			if len(spans) == 0 {
+				continue
+			}
+			results = append(results, SearchMatch{
+				Path:  note.Path,
+				Line:  offset + i + 1,
+				Text:  line,
+				Spans: spans,
+			})
+		}
+	}
+	return results
+}
+
+// Snippet returns the matching line with each match highlighted when stdout is a terminal, long lines are cut
+// down around the first match
+func (m SearchMatch) Snippet() string {
+	highlight := highlightMatches()
+	start, end := 0, len(m.Text)
+	if end-start > SNIPPET_WIDTH {
+		start = m.Spans[0][0] - SNIPPET_WIDTH/4
+		if start < 0 {
+			start = 0
+		}
+		end = start + SNIPPET_WIDTH
+		if end > len(m.Text) {
+			end = len(m.Text)
+		}
+		for start > 0 && !utf8.RuneStart(m.Text[start]) {
+			start--
+		}
+		for end < len(m.Text) && !utf8.RuneStart(m.Text[end]) {
+			end++
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	cur := start
+	for _, span := range m.Spans {
+		from, to := span[0], span[1]
+		if to <= cur || from >= end {
+			continue
+		}
+		if from < cur {
+			from = cur
+		}
+		if to > end {
+			to = end
+		}
+		b.WriteString(m.Text[cur:from])
+		if highlight {
+			b.WriteString(matchStyle.Render(m.Text[from:to]))
+		} else {
+			b.WriteString(m.Text[from:to])
+		}
+		cur = to
+	}
+	b.WriteString(m.Text[cur:end])
+	if end < len(m.Text) {
+		b.WriteString("…")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var (
+	searchIgnoreCase bool
+	searchRegex      bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:     "search",
+	Aliases: []string{"s"},
+	Short:   "searches the contents of notes",
+	Long:    "searches the contents of notes, printing the file, line and a snippet of every match. the query is matched literally unless --regex is given.",
+	Example: "notes search [-i] [--regex] <query>",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		matcher, err := NewSearchMatcher(strings.Join(args, " "), searchIgnoreCase, searchRegex)
+		if err != nil {
+			fmt.Printf("Problem trying to search: %v", err)
+			return
+		}
+		notes, err := collectFiles(false)
+		if err != nil {
+			fmt.Printf("Problem trying to search: %v", err)
+			return
+		}
+		err = writeOutput(SearchNotes(notes, matcher), func(match SearchMatch) {
+			fmt.Printf("%v:%v: %v\n", match.Path, match.Line, match.Snippet())
+		})
+		if err != nil {
+			fmt.Printf("Problem trying to output matches: %v", err)
+		}
+	},
+}
+
+func init() {
+	searchCmd.Flags().BoolVarP(&searchIgnoreCase, "ignore-case", "i", false, "match case-insensitively")
+	searchCmd.Flags().BoolVarP(&searchRegex, "regex", "r", false, "treat the query as a regular expression")
+}
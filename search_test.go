@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSearchNotes(t *testing.T) {
+	notes := []Note{
+		{
+			Path:      "b.txt",
+			Content:   "\nfirst line\nSecond Line with a line\n",
+			rawHeader: "title: b\ntags:\n",
+		},
+		{
+			Path:      "a.txt",
+			Content:   "nothing to see here\nline 2\n",
+			rawHeader: "title: a\n",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		ignoreCase bool
+		useRegex   bool
+		want       []SearchMatch
+	}{
+		{
+			name:  "literal",
+			query: "line",
+			want: []SearchMatch{
+				{Path: "a.txt", Line: 4, Text: "line 2", Spans: [][]int{{0, 4}}},
+				{Path: "b.txt", Line: 5, Text: "first line", Spans: [][]int{{6, 10}}},
+				{Path: "b.txt", Line: 6, Text: "Second Line with a line", Spans: [][]int{{19, 23}}},
+			},
+		},
+		{
+			name:       "case insensitive",
+			query:      "second line",
+			ignoreCase: true,
+			want: []SearchMatch{
+				{Path: "b.txt", Line: 6, Text: "Second Line with a line", Spans: [][]int{{0, 11}}},
+			},
+		},
+		{
+			name:     "regex",
+			query:    `^\w+ \d$`,
+			useRegex: true,
+			want: []SearchMatch{
+				{Path: "a.txt", Line: 4, Text: "line 2", Spans: [][]int{{0, 6}}},
+			},
+		},
+		{
+			name:  "literal does not treat query as regex",
+			query: `^\w+`,
+			want:  []SearchMatch{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewSearchMatcher(tt.query, tt.ignoreCase, tt.useRegex)
+			if err != nil {
+				t.Fatalf("unexpected error creating matcher: %v", err)
+			}
+			got := SearchNotes(notes, matcher)
+			if !reflect.DeepEqual(tt.want, got) {
+				t.Errorf("match mismatch:\nexpected: %+v\ngot: %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	old := highlightMatches
+	defer func() { highlightMatches = old }()
+	long := strings.Repeat("a", 60) + " needle " + strings.Repeat("b", 60)
+	tests := []struct {
+		name      string
+		match     SearchMatch
+		highlight bool
+		want      string
+	}{
+		{
+			name:  "plain when piped",
+			match: SearchMatch{Text: "first and first", Spans: [][]int{{0, 5}, {10, 15}}},
+			want:  "first and first",
+		},
+		{
+			name:  "long line cut around the match",
+			match: SearchMatch{Text: long, Spans: [][]int{{61, 67}}},
+			want:  "…" + long[41:121] + "…",
+		},
+		{
+			name:      "highlighted in a terminal",
+			match:     SearchMatch{Text: "first and first", Spans: [][]int{{0, 5}}},
+			highlight: true,
+			want:      matchStyle.Render("first") + " and first",
+		},
+	}
+	for _, tt := range tests {
+		highlightMatches = func() bool { return tt.highlight }
+		if got := tt.match.Snippet(); got != tt.want {
+			t.Errorf("%v: snippet mismatch:\nexpected: %q\ngot: %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -121,6 +121,15 @@ type model struct {
 }
 
 func NewFileSelector(title string, headerOnly bool) (model, error) {
+	notes, err := collectFiles(headerOnly)
+	if err != nil {
+		return model{}, fmt.Errorf("problem getting files: %w", err)
+	}
+	return NewNoteSelector(title, notes)
+}
+
+// NewNoteSelector is a selector over an already collected set of notes
+func NewNoteSelector(title string, notes []Note) (model, error) {
 	var (
 		//		itemGenerator randomItemGenerator
 		delegateKeys = newDelegateKeyMap()
@@ -128,10 +137,6 @@ func NewFileSelector(title string, headerOnly bool) (model, error) {
 	)
 
 	// Make initial list of items
-	notes, err := collectFiles(headerOnly, false)
-	if err != nil {
-		return model{}, fmt.Errorf("problem getting files: %w", err)
-	}
 	if len(notes) == 0 {
 		return model{}, fmt.Errorf("no files found")
 	}
@@ -162,6 +167,30 @@ func NewFileSelector(title string, headerOnly bool) (model, error) {
 	}, nil
 }
 
+// SelectNote runs the file selector and returns whichever note was picked
+func SelectNote(title string, headerOnly bool) (Note, error) {
+	mod, err := NewFileSelector(title, headerOnly)
+	if err != nil {
+		return Note{}, err
+	}
+	return runSelector(mod)
+}
+
+func runSelector(mod model) (Note, error) {
+	m, err := tea.NewProgram(mod).StartReturningModel()
+	if err != nil {
+		return Note{}, fmt.Errorf("problem trying to get selection: %w", err)
+	}
+	mod, ok := m.(model)
+	if !ok {
+		return Note{}, fmt.Errorf("could not read selection")
+	}
+	if len(mod.choice.Path) == 0 {
+		return Note{}, fmt.Errorf("no file selected")
+	}
+	return mod.choice, nil
+}
+
 func (m model) Init() tea.Cmd {
 	return tea.EnterAltScreen
 }
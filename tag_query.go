@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/sahilm/fuzzy"
+)
+
+const (
+	MATCH_EXACT  = "exact"
+	MATCH_PREFIX = "prefix"
+	MATCH_FUZZY  = "fuzzy"
+)
+
+// TagQuery is a boolean expression over a note's tags, see ParseTagQuery
+type TagQuery interface {
+	Matches(tags []string) bool
+}
+
+type tagTerm struct {
+	tag  string
+	mode string
+}
+
+func (t tagTerm) Matches(tags []string) bool {
+	switch t.mode {
+	case MATCH_EXACT:
+		for _, tag := range tags {
+			if strings.EqualFold(tag, t.tag) {
+				return true
+			}
+		}
+		return false
+	case MATCH_PREFIX:
+		for _, tag := range tags {
+			if strings.HasPrefix(strings.ToLower(tag), strings.ToLower(t.tag)) {
+				return true
+			}
+		}
+		return false
+	}
+	return fuzzy.Find(t.tag, tags).Len() > 0
+}
+
+type notQuery struct {
+	query TagQuery
+}
+
+func (n notQuery) Matches(tags []string) bool {
+	return !n.query.Matches(tags)
+}
+
+type andQuery []TagQuery
+
+func (a andQuery) Matches(tags []string) bool {
+	for _, q := range a {
+		if !q.Matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+type orQuery []TagQuery
+
+func (o orQuery) Matches(tags []string) bool {
+	for _, q := range o {
+		if q.Matches(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenizeTagQuery(input string) []string {
+	tokens := make([]string, 0)
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range input {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type tagQueryParser struct {
+	tokens []string
+	pos    int
+	mode   string
+}
+
+func (p *tagQueryParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *tagQueryParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+// or := and (OR and)*
+func (p *tagQueryParser) parseOr() (TagQuery, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	result := orQuery{first}
+	for p.peek() == "OR" {
+		p.next()
+		q, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, q)
+	}
+	if len(result) == 1 {
+		return first, nil
+	}
+	return result, nil
+}
+
+// and := unary ([AND] unary)*, terms next to each other are implicitly AND'd
+func (p *tagQueryParser) parseAnd() (TagQuery, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	result := andQuery{first}
+	for {
+		token := p.peek()
+		if token == "" || token == ")" || token == "OR" {
+			break
+		}
+		if token == "AND" {
+			p.next()
+		}
+		q, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, q)
+	}
+	if len(result) == 1 {
+		return first, nil
+	}
+	return result, nil
+}
+
+// unary := (NOT | - | +) unary | ( or ) | [tag:]name
+func (p *tagQueryParser) parseUnary() (TagQuery, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of query")
+	case token == ")" || token == "OR" || token == "AND":
+		return nil, fmt.Errorf("unexpected `%v`", token)
+	case token == "NOT" || token == "-":
+		q, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notQuery{query: q}, nil
+	case token == "+":
+		return p.parseUnary()
+	case token == "(":
+		q, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing `)`")
+		}
+		return q, nil
+	case strings.HasPrefix(token, "-"):
+		return notQuery{query: p.term(token[1:])}, nil
+	case strings.HasPrefix(token, "+"):
+		return p.term(token[1:]), nil
+	}
+	return p.term(token), nil
+}
+
+func (p *tagQueryParser) term(token string) TagQuery {
+	if len(token) > len("tag:") && strings.EqualFold(token[:len("tag:")], "tag:") {
+		token = token[len("tag:"):]
+	}
+	return tagTerm{tag: token, mode: p.mode}
+}
+
+// ParseTagQuery parses expressions like `work +urgent -archived` or `tag:a OR (tag:b NOT c)`.
+// terms next to each other must all match, + is the same as a plain term, - and NOT negate the term after them,
+// OR needs either side to match and parentheses group. mode is how each term gets compared to a note's tags
+func ParseTagQuery(input, mode string) (TagQuery, error) {
+	switch mode {
+	case MATCH_EXACT, MATCH_PREFIX, MATCH_FUZZY:
+	default:
+		return nil, fmt.Errorf("unknown match mode `%v`, expected one of %v, %v or %v", mode, MATCH_EXACT, MATCH_PREFIX, MATCH_FUZZY)
+	}
+	tokens := tokenizeTagQuery(input)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty tag query")
+	}
+	p := &tagQueryParser{tokens: tokens, mode: mode}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag query: %w", err)
+	}
+	if p.pos < len(tokens) {
+		return nil, fmt.Errorf("invalid tag query: unexpected `%v`", p.peek())
+	}
+	return q, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestParseTagQuery(t *testing.T) {
+	tags := []string{"work", "Urgent", "planning"}
+
+	tests := []struct {
+		name    string
+		query   string
+		mode    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "single exact", query: "work", mode: MATCH_EXACT, want: true},
+		{name: "exact ignores case", query: "urgent", mode: MATCH_EXACT, want: true},
+		{name: "exact needs whole tag", query: "plan", mode: MATCH_EXACT, want: false},
+		{name: "prefix", query: "plan", mode: MATCH_PREFIX, want: true},
+		{name: "fuzzy", query: "pln", mode: MATCH_FUZZY, want: true},
+		{name: "implicit and", query: "work +urgent", mode: MATCH_EXACT, want: true},
+		{name: "implicit and missing tag", query: "work archived", mode: MATCH_EXACT, want: false},
+		{name: "explicit and", query: "work AND urgent", mode: MATCH_EXACT, want: true},
+		{name: "minus excludes", query: "work -archived", mode: MATCH_EXACT, want: true},
+		{name: "minus excludes present tag", query: "work -urgent", mode: MATCH_EXACT, want: false},
+		{name: "not keyword", query: "NOT work", mode: MATCH_EXACT, want: false},
+		{name: "or", query: "tag:archived OR tag:work", mode: MATCH_EXACT, want: true},
+		{name: "or neither", query: "tag:archived OR tag:home", mode: MATCH_EXACT, want: false},
+		{name: "and binds tighter than or", query: "archived OR work -urgent", mode: MATCH_EXACT, want: false},
+		{name: "parentheses", query: "(archived OR work) urgent", mode: MATCH_EXACT, want: true},
+		{name: "negated group", query: "-(archived OR work)", mode: MATCH_EXACT, want: false},
+		{name: "nested groups", query: "((work))", mode: MATCH_EXACT, want: true},
+		{name: "empty query", query: "  ", mode: MATCH_EXACT, wantErr: true},
+		{name: "unbalanced parentheses", query: "(work", mode: MATCH_EXACT, wantErr: true},
+		{name: "stray closing parenthesis", query: "work)", mode: MATCH_EXACT, wantErr: true},
+		{name: "dangling or", query: "work OR", mode: MATCH_EXACT, wantErr: true},
+		{name: "unknown mode", query: "work", mode: "regex", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := ParseTagQuery(tt.query, tt.mode)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, but did not get one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error parsing query: %v", err)
+			}
+			if got := query.Matches(tags); got != tt.want {
+				t.Errorf("match mismatch for %q: expected %v, got %v", tt.query, tt.want, got)
+			}
+		})
+	}
+}
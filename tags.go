@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	SORT_NAME  = "name"
+	SORT_COUNT = "count"
+)
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagInventory counts how many notes use each tag. tags that only differ by case are counted separately
+// so they show up as near duplicates
+func TagInventory(notes []Note, sortBy string) ([]TagCount, error) {
+	counts := make(map[string]int)
+	for _, note := range notes {
+		for _, tag := range note.Tags {
+			counts[tag]++
+		}
+	}
+	results := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		results = append(results, TagCount{Tag: tag, Count: count})
+	}
+
+	byName := func(i, j int) bool {
+		a, b := strings.ToLower(results[i].Tag), strings.ToLower(results[j].Tag)
+		if a == b {
+			return results[i].Tag < results[j].Tag
+		}
+		return a < b
+	}
+	switch sortBy {
+	case SORT_NAME:
+		sort.Slice(results, byName)
+	case SORT_COUNT:
+		sort.Slice(results, func(i, j int) bool {
+			if results[i].Count == results[j].Count {
+				return byName(i, j)
+			}
+			return results[i].Count > results[j].Count
+		})
+	default:
+		return nil, fmt.Errorf("unknown sort `%v`, expected %v or %v", sortBy, SORT_NAME, SORT_COUNT)
+	}
+	return results, nil
+}
+
+// editDistance is the levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// similarTags reports whether two tags are probably meant to be the same tag,
+// either differing only by case or by a single typo in a longer tag
+func similarTags(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if a == b {
+		return true
+	}
+	if len([]rune(a)) < 4 || len([]rune(b)) < 4 {
+		return false
+	}
+	return editDistance(a, b) <= 1
+}
+
+// SimilarTags groups together tags from the inventory that are near duplicates of each other,
+// tags without any near duplicates are left out
+func SimilarTags(inventory []TagCount) [][]TagCount {
+	group := make([]int, len(inventory))
+	for i := range group {
+		group[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+		return group[i]
+	}
+	for i := range inventory {
+		for j := i + 1; j < len(inventory); j++ {
+			if similarTags(inventory[i].Tag, inventory[j].Tag) {
+				group[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]TagCount)
+	order := make([]int, 0)
+	for i, tag := range inventory {
+		g := find(i)
+		if _, ok := members[g]; !ok {
+			order = append(order, g)
+		}
+		members[g] = append(members[g], tag)
+	}
+	results := make([][]TagCount, 0)
+	for _, g := range order {
+		if len(members[g]) > 1 {
+			results = append(results, members[g])
+		}
+	}
+	return results
+}
+
+// uniqueTags drops tags that are repeated regardless of case, keeping the first
+func uniqueTags(tags []string) []string {
+	results := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !hasAnyTag(results, []string{tag}) {
+			results = append(results, tag)
+		}
+	}
+	return results
+}
+
+// retagValue replaces any of the from tags in a tags header value with to, dropping duplicates that creates
+func retagValue(value string, from []string, to string) string {
+	tags := splitTags(value)
+	for i, tag := range tags {
+		if hasAnyTag([]string{tag}, from) {
+			tags[i] = to
+		}
+	}
+	return strings.Join(uniqueTags(tags), ", ")
+}
+
+type Retag struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// RetagNotes works out the new contents of every note tagged with any of the from tags once they're replaced with to.
+// tags match regardless of case, the same as the parser treats them
+func RetagNotes(notes []Note, from []string, to string) ([]Retag, error) {
+	if len(strings.TrimSpace(to)) == 0 || strings.Contains(to, ",") {
+		return nil, fmt.Errorf("invalid tag `%v`", to)
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Path < notes[j].Path })
+
+	results := make([]Retag, 0)
+	for _, note := range notes {
+		if !hasAnyTag(note.Tags, from) {
+			continue
+		}
+		before, err := os.ReadFile(note.Path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read file: %v, %w", note.Path, err)
+		}
+		after, changed := formatFor(note.Path).RewriteField(before, "tags", func(value string) string {
+			return retagValue(value, from, to)
+		})
+		if changed {
+			results = append(results, Retag{Path: note.Path, Before: before, After: after})
+		}
+	}
+	return results, nil
+}
+
+func hasAnyTag(tags []string, wanted []string) bool {
+	for _, w := range wanted {
+		if (tagTerm{tag: w, mode: MATCH_EXACT}).Matches(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func applyRetags(retags []Retag, dryRun bool) error {
+	for _, retag := range retags {
+		if dryRun {
+			fmt.Print(lineDiff(retag.Path, retag.Before, retag.After))
+			continue
+		}
+		if err := writeNoteFile(retag.Path, retag.After); err != nil {
+			return err
+		}
+		fmt.Printf("Retagged %v\n", retag.Path)
+	}
+	if len(retags) == 0 {
+		fmt.Println("No notes needed retagging")
+	}
+	return nil
+}
+
+func retag(from []string, to string) error {
+	notes, err := collectFiles(true)
+	if err != nil {
+		return err
+	}
+	retags, err := RetagNotes(notes, from, to)
+	if err != nil {
+		return err
+	}
+	return applyRetags(retags, tagsDryRun)
+}
+
+var (
+	tagsSort    string
+	tagsSimilar bool
+	tagsDryRun  bool
+	tagsInto    string
+)
+
+var tagsCmd = &cobra.Command{
+	Use:     "tags",
+	Short:   "lists every tag in the notebook with how many notes use it",
+	Long:    "lists every tag in the notebook with how many notes use it. --similar lists groups of tags that only differ by case or a typo instead.",
+	Example: "notes tags [--sort name|count] [--similar]",
+	Args:    cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		notes, err := collectFiles(true)
+		if err != nil {
+			fmt.Printf("Problem trying to list tags: %v", err)
+			return
+		}
+		inventory, err := TagInventory(notes, tagsSort)
+		if err != nil {
+			fmt.Printf("Problem trying to list tags: %v", err)
+			return
+		}
+		if tagsSimilar {
+			err = writeOutput(SimilarTags(inventory), func(group []TagCount) {
+				names := make([]string, len(group))
+				for i, tag := range group {
+					names[i] = fmt.Sprintf("%v (%v)", tag.Tag, tag.Count)
+				}
+				fmt.Println(strings.Join(names, ", "))
+			})
+		} else {
+			err = writeOutput(inventory, func(tag TagCount) {
+				fmt.Printf("%v\t%v\n", tag.Count, tag.Tag)
+			})
+		}
+		if err != nil {
+			fmt.Printf("Problem trying to output tags: %v", err)
+		}
+	},
+}
+
+var tagsRenameCmd = &cobra.Command{
+	Use:     "rename",
+	Short:   "renames a tag in every note that has it",
+	Long:    "renames a tag in every note that has it, only the tags line of each note's header is rewritten.",
+	Example: "notes tags rename [--dry-run] <old> <new>",
+	Args:    cobra.ExactArgs(2),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := retag(args[:1], args[1]); err != nil {
+			fmt.Printf("Problem trying to rename tag: %v", err)
+		}
+	},
+}
+
+var tagsMergeCmd = &cobra.Command{
+	Use:     "merge",
+	Short:   "replaces several tags with a single tag in every note that has them",
+	Long:    "replaces several tags with a single tag in every note that has them, only the tags line of each note's header is rewritten.",
+	Example: "notes tags merge [--dry-run] <a> <b> --into <c>",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		if err := retag(args, tagsInto); err != nil {
+			fmt.Printf("Problem trying to merge tags: %v", err)
+		}
+	},
+}
+
+func init() {
+	tagsCmd.Flags().StringVar(&tagsSort, "sort", SORT_COUNT, "sort tags by "+SORT_NAME+" or "+SORT_COUNT)
+	tagsCmd.Flags().BoolVar(&tagsSimilar, "similar", false, "list near duplicate tags instead")
+	tagsCmd.PersistentFlags().BoolVar(&tagsDryRun, "dry-run", false, "show what would change without writing anything")
+	tagsMergeCmd.Flags().StringVar(&tagsInto, "into", "", "tag to merge into")
+	_ = tagsMergeCmd.MarkFlagRequired("into")
+
+	tagsCmd.AddCommand(tagsRenameCmd)
+	tagsCmd.AddCommand(tagsMergeCmd)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTagInventory(t *testing.T) {
+	notes := []Note{
+		{Tags: []string{"work", "planning"}},
+		{Tags: []string{"Work", "urgent"}},
+		{Tags: []string{"work", "planing"}},
+		{Tags: []string{"work", "ux", "ui"}},
+	}
+
+	byCount, err := TagInventory(notes, SORT_COUNT)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TagCount{
+		{Tag: "work", Count: 3},
+		{Tag: "planing", Count: 1},
+		{Tag: "planning", Count: 1},
+		{Tag: "ui", Count: 1},
+		{Tag: "urgent", Count: 1},
+		{Tag: "ux", Count: 1},
+		{Tag: "Work", Count: 1},
+	}
+	if !reflect.DeepEqual(want, byCount) {
+		t.Errorf("count sort mismatch:\nexpected: %v\ngot: %v", want, byCount)
+	}
+
+	byName, err := TagInventory(notes, SORT_NAME)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if byName[0].Tag != "planing" || byName[len(byName)-1].Tag != "work" {
+		t.Errorf("name sort mismatch: %v", byName)
+	}
+
+	if _, err := TagInventory(notes, "size"); err == nil {
+		t.Errorf("expected error for unknown sort")
+	}
+
+	wantSimilar := [][]TagCount{
+		{{Tag: "planing", Count: 1}, {Tag: "planning", Count: 1}},
+		{{Tag: "Work", Count: 1}, {Tag: "work", Count: 3}},
+	}
+	if got := SimilarTags(byName); !reflect.DeepEqual(wantSimilar, got) {
+		t.Errorf("similar tags mismatch:\nexpected: %v\ngot: %v", wantSimilar, got)
+	}
+}
+
+func TestRetagNotes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.txt")
+	contents := "title: retag me\r\nTags:  Planing, work,planning \r\nauthor: me\r\n------\r\ntags: not, in, the, header\r\n"
+	if err := os.WriteFile(path, []byte(contents), 0660); err != nil {
+		t.Fatalf("could not write test note: %v", err)
+	}
+	notes := []Note{
+		{Path: path, Tags: []string{"Planing", "work", "planning"}},
+		{Path: filepath.Join(dir, "untouched.txt"), Tags: []string{"home"}},
+	}
+
+	retags, err := RetagNotes(notes, []string{"planing", "PLANNING"}, "planning")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(retags) != 1 {
+		t.Fatalf("expected a single note to be retagged, got %v", len(retags))
+	}
+	want := "title: retag me\r\nTags:  planning, work\r\nauthor: me\r\n------\r\ntags: not, in, the, header\r\n"
+	if got := string(retags[0].After); got != want {
+		t.Errorf("retag mismatch:\nexpected: %q\ngot: %q", want, got)
+	}
+
+	if _, err := RetagNotes(notes, []string{"work"}, "a,b"); err == nil {
+		t.Errorf("expected an error retagging to an invalid tag")
+	}
+}
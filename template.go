@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const TEMPLATE_DIR = "templates"
+const TEMPLATE_EXTENSION = ".txt"
+
+// TemplateData is what's available to note templates, e.g. {{.Title}} or {{.Now.Format "15:04"}}
+type TemplateData struct {
+	Title string
+	// today, formatted with the configured date format
+	Date   string
+	Now    time.Time
+	Author string
+	// path of the new note relative to the notebook
+	Path string
+}
+
+// where prompt reads answers from, shared so that several prompts can read from the same input
+var promptInput = bufio.NewReader(os.Stdin)
+
+func templatesDir(root string) string {
+	return filepath.Join(root, METADATA_DIR, TEMPLATE_DIR)
+}
+
+// ListTemplates returns the names of every template in the notebook
+func ListTemplates(root string) ([]string, error) {
+	entries, err := os.ReadDir(templatesDir(root))
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read templates: %w", err)
+	}
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), TEMPLATE_EXTENSION) {
+			names = append(names, strings.TrimSuffix(entry.Name(), TEMPLATE_EXTENSION))
+		}
+	}
+	return names, nil
+}
+
+// currentAuthor is the author setting, falling back to the name of the logged in user
+func currentAuthor() string {
+	if len(settings.Author) > 0 {
+		return settings.Author
+	}
+	if u, err := user.Current(); err == nil {
+		if len(u.Name) > 0 {
+			return u.Name
+		}
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// templatePrompt asks for a value on the command line, each label is only asked once per note
+func templatePrompt(in *bufio.Reader, out io.Writer) func(label string) (string, error) {
+	answers := make(map[string]string)
+	return func(label string) (string, error) {
+		if answer, ok := answers[label]; ok {
+			return answer, nil
+		}
+		fmt.Fprintf(out, "%v: ", label)
+		answer, err := in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("could not read answer for %v: %w", label, err)
+		}
+		answer = strings.TrimSpace(answer)
+		answers[label] = answer
+		return answer, nil
+	}
+}
+
+// RenderTemplate fills in the named template from the notebook at root. the result has to be a valid note, and is
+// written in the format of data.Path
+func RenderTemplate(root, name string, data TemplateData) (string, error) {
+	name = strings.TrimSuffix(name, TEMPLATE_EXTENSION)
+	// templates all sit directly in the templates directory, so a name can't lead anywhere else
+	if len(strings.TrimSpace(name)) == 0 || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("`%v` is not a template name, templates are picked by their file name in %v", name, templatesDir(root))
+	}
+	templatePath := filepath.Join(templatesDir(root), name+TEMPLATE_EXTENSION)
+	raw, err := os.ReadFile(templatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		available, _ := ListTemplates(root)
+		if len(available) == 0 {
+			return "", fmt.Errorf("template `%v` does not exist, templates go in %v", name, templatesDir(root))
+		}
+		return "", fmt.Errorf("template `%v` does not exist, expected one of: %v", name, strings.Join(available, ", "))
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read template: %w", err)
+	}
+
+	tmpl, err := template.New(name).
+		Funcs(template.FuncMap{"prompt": templatePrompt(promptInput, os.Stdout)}).
+		Option("missingkey=error").
+		Parse(string(raw))
+	if err != nil {
+		return "", fmt.Errorf("could not parse template `%v`: %w", name, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("could not fill in template `%v`: %w", name, err)
+	}
+	// templates are written as txt notes, and carried over to the format of the note they're for
+	from := txtFormat{}
+	note, err := from.Parse(bytes.NewReader(out.Bytes()), templatePath, true)
+	if err != nil {
+		return "", fmt.Errorf("template `%v` does not make a valid note: %w", name, err)
+	}
+	to := formatFor(data.Path)
+	if _, toTxt := to.(txtFormat); toTxt {
+		return out.String(), nil
+	}
+	_, content, _ := from.Split(out.Bytes())
+	fields, _ := convertFields(note.Fields, to)
+	rendered, err := to.Render(fields, string(content))
+	if err != nil {
+		return "", fmt.Errorf("could not write template `%v` as a %v note: %w", name, to.Name(), err)
+	}
+	return string(rendered), nil
+}
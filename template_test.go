@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(templatesDir(root), 0770); err != nil {
+		t.Fatalf("could not create templates directory: %v", err)
+	}
+	meeting := `title: {{.Title}}
+tags: meeting
+author: {{.Author}}
+------
+{{.Date}} {{.Now.Format "15:04"}}
+attendees: {{prompt "Attendees"}}
+again: {{prompt "Attendees"}}
+`
+	if err := os.WriteFile(filepath.Join(templatesDir(root), "meeting.txt"), []byte(meeting), 0660); err != nil {
+		t.Fatalf("could not write template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templatesDir(root), "broken.txt"), []byte("no header here\n"), 0660); err != nil {
+		t.Fatalf("could not write template: %v", err)
+	}
+
+	oldInput := promptInput
+	defer func() { promptInput = oldInput }()
+	promptInput = bufio.NewReader(strings.NewReader("Alice, Bob\n"))
+
+	data := TemplateData{
+		Title:  "standup",
+		Date:   "2026-10-17",
+		Now:    time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+		Author: "someone",
+	}
+	got, err := RenderTemplate(root, "meeting", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `title: standup
+tags: meeting
+author: someone
+------
+2026-10-17 09:30
+attendees: Alice, Bob
+again: Alice, Bob
+`
+	if got != want {
+		t.Errorf("template mismatch:\nexpected: %q\ngot: %q", want, got)
+	}
+
+	if _, err := RenderTemplate(root, "broken", data); err == nil {
+		t.Errorf("expected an error for a template that isn't a valid note")
+	}
+	for _, bad := range []string{"", "..", "../../config", "sub/meeting", `..\meeting`, "/etc/passwd"} {
+		if _, err := RenderTemplate(root, bad, data); err == nil || !strings.Contains(err.Error(), "is not a template name") {
+			t.Errorf("expected template name %q to be rejected, got %v", bad, err)
+		}
+	}
+	_, err = RenderTemplate(root, "missing", data)
+	if err == nil || !strings.Contains(err.Error(), "broken, meeting") {
+		t.Errorf("expected missing template error to list the available templates, got %v", err)
+	}
+}